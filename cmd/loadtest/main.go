@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/nickwallen/battlesnake-snacks/internal/loadtest"
+	"github.com/nickwallen/battlesnake-snacks/internal/replay"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	url := flag.String("url", "http://localhost:8000", "the base URL of the snake server")
+	concurrency := flag.Int("concurrency", 8, "the number of games played at once")
+	rate := flag.Float64("rate", 0, "the most requests sent per second across all games; 0 for no limit")
+	repeat := flag.Int("repeat", 1, "the number of times each game is played")
+	timeout := flag.Duration("timeout", 500*time.Millisecond, "the longest to wait for each response")
+	as := flag.String("as", "", "play the turns of the snake with this name or ID; required for games imported from the CLI or the engine")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <game>...\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Games can be recorded by the snake server, written by 'battlesnake play --output' or exported from the engine.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	games := make([]loadtest.Game, 0)
+	for _, path := range flag.Args() {
+		records, err := replay.ImportFile(path)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to read '%s'.", path)
+		}
+		records, err = replay.SelectPerspective(records, *as)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to choose a snake in '%s'; use -as.", path)
+		}
+		games = append(games, loadtest.Games(records)...)
+	}
+
+	// Stop early, but still report, on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config := loadtest.Config{
+		URL:         *url,
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Repeat:      *repeat,
+		Timeout:     *timeout,
+	}
+	report, err := loadtest.Run(ctx, config, games)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to load test.")
+	}
+	err = report.Write(os.Stdout)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to report.")
+	}
+	if report.ErrorCount() > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/puzzle"
+	"github.com/nickwallen/battlesnake-snacks/internal/replay"
+	"github.com/nickwallen/battlesnake-snacks/internal/snacks"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sort"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	snakeName := flag.String("snake", "BATTLE", "the snake to replay; DUMB, HUNGRY, SOLO, BATTLE, SQUAD or CONSTRICTOR")
+	squadPrefix := flag.String("squad-prefix", "", "the name prefix shared by teammates of the SQUAD snake")
+	as := flag.String("as", "", "replay the turns of the snake with this name or ID; required for games imported from the CLI or the engine")
+	extract := flag.Int("extract", -1, "print a puzzle that forbids the move recorded on this turn instead of replaying")
+	verbose := flag.Bool("v", false, "log every move made by the snake")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <game>...\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Games can be recorded by the snake server, written by 'battlesnake play --output' or exported from the engine.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !*verbose {
+		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	}
+
+	snake, err := snacks.NewSnake(*snakeName, snacks.NewSquad(*squadPrefix))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to replay.")
+	}
+
+	// Replays must not depend on how fast this machine is
+	snake.SetSearch(snacks.SearchConfig{Deterministic: true})
+
+	for _, path := range flag.Args() {
+		records, err := replay.ImportFile(path)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to read '%s'.", path)
+		}
+		records, err = replay.SelectPerspective(records, *as)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to choose a snake in '%s'; use -as.", path)
+		}
+		if *extract >= 0 {
+			extractPuzzle(path, records, *extract)
+			continue
+		}
+		report(path, replay.Replay(snake, records))
+	}
+}
+
+func report(path string, result replay.Result) {
+	fmt.Printf("%s: game %s, %d of %d turn(s) differ\n", path, result.GameID, len(result.Differences), result.Turns)
+	for _, diff := range result.Differences {
+		fmt.Printf("\nTurn %d: recorded %s, replayed %s\n", diff.Turn, diff.Recorded.Response.Move, diff.Replayed.Response.Move)
+		fmt.Print(battlesnake.Render(diff.State))
+		fmt.Println("  recorded:")
+		printScores(diff.Recorded.Scores)
+		printExplanation(diff.Recorded.Explanation)
+		fmt.Println("  replayed:")
+		printScores(diff.Replayed.Scores)
+		printExplanation(diff.Replayed.Explanation)
+	}
+}
+
+// printExplanation Prints the vetoes, reasons and tie-breaks behind a move.
+func printExplanation(explanation *battlesnake.Explanation) {
+	if explanation == nil {
+		return
+	}
+	for _, strategy := range explanation.Strategies {
+		for _, move := range strategy.Vetoes {
+			fmt.Printf("    %-24s %s unsafe\n", strategy.Name, move)
+		}
+		for _, reason := range strategy.Reasons {
+			fmt.Printf("    %-24s %s\n", strategy.Name, reason)
+		}
+	}
+	if len(explanation.TieBreak) > 0 {
+		fmt.Printf("    %-24s %s\n", "tie-break", explanation.TieBreak)
+	}
+}
+
+// extractPuzzle Prints a puzzle that turns a move from a recorded game into a regression test.
+func extractPuzzle(path string, records []battlesnake.Record, turn int) {
+	for _, record := range records {
+		if record.Type != battlesnake.RecordMove || record.State.Turn != turn {
+			continue
+		}
+		description := fmt.Sprintf("Turn %d of game %s", turn, record.State.Game.ID)
+		p, err := puzzle.FromRecord(record, description)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to extract a puzzle from '%s'.", path)
+		}
+		fmt.Print(puzzle.Format(p))
+		return
+	}
+	log.Fatal().Msgf("No move recorded on turn %d of '%s'.", turn, path)
+}
+
+func printScores(scores map[string]map[battlesnake.Move]int) {
+	labels := make([]string, 0, len(scores))
+	for label := range scores {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		moves := scores[label]
+		fmt.Printf("    %-24s %s %4d  %s %4d  %s %4d  %s %4d\n", label,
+			battlesnake.UP, moves[battlesnake.UP],
+			battlesnake.DOWN, moves[battlesnake.DOWN],
+			battlesnake.LEFT, moves[battlesnake.LEFT],
+			battlesnake.RIGHT, moves[battlesnake.RIGHT])
+	}
+}
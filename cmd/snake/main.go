@@ -6,11 +6,31 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
+	"strconv"
+	"time"
 )
 
 const (
-	EnvPort  = "PORT"
-	EnvSnake = "SNAKE"
+	EnvPort        = "PORT"
+	EnvSnake       = "SNAKE"
+	EnvSquadPrefix = "SQUAD_PREFIX"
+	EnvRecordDir   = "RECORD_DIR"
+	EnvProfileDir  = "PROFILE_DIR"
+
+	EnvListenAddr      = "LISTEN_ADDR"
+	EnvReadTimeout     = "READ_TIMEOUT"
+	EnvWriteTimeout    = "WRITE_TIMEOUT"
+	EnvIdleTimeout     = "IDLE_TIMEOUT"
+	EnvShutdownTimeout = "SHUTDOWN_TIMEOUT"
+	EnvTLSCertFile     = "TLS_CERT_FILE"
+	EnvTLSKeyFile      = "TLS_KEY_FILE"
+
+	EnvLogLevel       = "LOG_LEVEL"
+	EnvLogFormat      = "LOG_FORMAT"
+	EnvLogSampleTurns = "LOG_SAMPLE_TURNS"
+
+	EnvSearchWorkers = "SEARCH_WORKERS"
+	EnvSearchBudget  = "SEARCH_BUDGET"
 
 	PortDefault = "8000"
 )
@@ -18,6 +38,24 @@ const (
 func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
+	// How should the snake log?
+	logConfig := battlesnake.LogConfig{
+		Level:  os.Getenv(EnvLogLevel),
+		Format: os.Getenv(EnvLogFormat),
+	}
+	if value := os.Getenv(EnvLogSampleTurns); len(value) > 0 {
+		sampleTurns, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unexpected value '%s' for env var '%s'.", value, EnvLogSampleTurns)
+		}
+		logConfig.SampleTurns = sampleTurns
+	}
+	logging, err := battlesnake.NewLogging(logConfig, os.Stderr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to configure logging.")
+	}
+	log.Logger = logging.Logger
+
 	// Which port to use?
 	port := os.Getenv(EnvPort)
 	if len(port) == 0 {
@@ -25,19 +63,68 @@ func main() {
 	}
 
 	// Which snake will battle?
-	var snake *snacks.StrategyDrivenSnake
-	switch os.Getenv(EnvSnake) {
-	case "DUMB":
-		snake = snacks.DumbSnake()
-	case "HUNGRY":
-		snake = snacks.HungrySnake()
-	case "SOLO":
-		snake = snacks.SoloSurvivalSnake()
-	case "BATTLE":
-		snake = snacks.BattleSnake()
-	default:
+	snake, err := snacks.NewSnake(os.Getenv(EnvSnake), snacks.NewSquad(os.Getenv(EnvSquadPrefix)))
+	if err != nil {
 		log.Fatal().Msgf("Unexpected value '%s' for env var '%s'.", os.Getenv(EnvSnake), EnvSnake)
 	}
 
-	battlesnake.RunServer(snake, port)
+	// Should games be recorded?
+	var recorder *battlesnake.Recorder
+	if dir := os.Getenv(EnvRecordDir); len(dir) > 0 {
+		recorder, err = battlesnake.NewRecorder(dir)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to record games to '%s'.", dir)
+		}
+	}
+
+	// Should opponents be remembered across games?
+	if dir := os.Getenv(EnvProfileDir); len(dir) > 0 {
+		profiles, err := snacks.NewProfileStore(dir)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unable to keep profiles in '%s'.", dir)
+		}
+		snake.SetProfiles(profiles)
+	}
+
+	// How hard should the snake search ahead?
+	var search snacks.SearchConfig
+	if value := os.Getenv(EnvSearchWorkers); len(value) > 0 {
+		workers, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Unexpected value '%s' for env var '%s'.", value, EnvSearchWorkers)
+		}
+		search.Workers = workers
+	}
+	durationFromEnv(EnvSearchBudget, &search.Budget)
+	snake.SetSearch(search)
+
+	// How should the snake be served?
+	config := battlesnake.DefaultServerConfig(port)
+	if addr := os.Getenv(EnvListenAddr); len(addr) > 0 {
+		config.Addr = addr
+	}
+	durationFromEnv(EnvReadTimeout, &config.ReadTimeout)
+	durationFromEnv(EnvWriteTimeout, &config.WriteTimeout)
+	durationFromEnv(EnvIdleTimeout, &config.IdleTimeout)
+	durationFromEnv(EnvShutdownTimeout, &config.ShutdownTimeout)
+	config.TLSCertFile = os.Getenv(EnvTLSCertFile)
+	config.TLSKeyFile = os.Getenv(EnvTLSKeyFile)
+
+	err = battlesnake.RunServer(snake, config, recorder, logging)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to serve the snake.")
+	}
+}
+
+// durationFromEnv Overrides a duration, like '5s', if the env var is set.
+func durationFromEnv(name string, duration *time.Duration) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Unexpected value '%s' for env var '%s'.", value, name)
+	}
+	*duration = parsed
 }
@@ -0,0 +1,225 @@
+package battlesnake
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// YouID The ID given to your snake by ParseBoard.
+const YouID = "you"
+
+// ParseBoard Returns the game state drawn by a diagram of the board. The diagram uses the same
+// symbols as Render; 'H' is your head and digits number your body from the head, upper case letters
+// are the heads of opponents and lower case letters their bodies, '*' is food and '#' is a hazard.
+// The top row of the diagram is the top of the board. Rows may separate squares with spaces and
+// may be labelled with coordinates as drawn by Render.
+//
+// Each snake is given a length equal to the number of squares it covers along with full health.
+// Your snake has the ID "you" and each opponent is identified by its letter.
+func ParseBoard(diagram string) (GameState, error) {
+	rows, err := parseRows(diagram)
+	if err != nil {
+		return GameState{}, err
+	}
+
+	state := GameState{
+		Board: Board{
+			Height:  len(rows),
+			Width:   len(rows[0]),
+			Food:    make([]Coord, 0),
+			Hazards: make([]Coord, 0),
+			Snakes:  make([]Snake, 0),
+		},
+	}
+	var head *Coord
+	body := make(map[Coord]rune)
+	opponentHeads := make(map[rune]Coord)
+	opponentBodies := make(map[rune]map[Coord]bool)
+	for i, row := range rows {
+		for x, symbol := range row {
+			coord := Coord{X: x, Y: len(rows) - 1 - i}
+			switch {
+			case symbol == SymbolEmpty:
+			case symbol == SymbolFood:
+				state.Board.Food = append(state.Board.Food, coord)
+			case symbol == SymbolHazard:
+				state.Board.Hazards = append(state.Board.Hazards, coord)
+			case symbol == SymbolHead:
+				if head != nil {
+					return GameState{}, fmt.Errorf("found more than one head 'H' at %s and %s", *head, coord)
+				}
+				head = &coord
+			case unicode.IsDigit(symbol):
+				body[coord] = symbol
+			case isOpponent(unicode.ToLower(symbol)) && unicode.IsUpper(symbol):
+				letter := unicode.ToLower(symbol)
+				if other, ok := opponentHeads[letter]; ok {
+					return GameState{}, fmt.Errorf("found more than one head '%c' at %s and %s", symbol, other, coord)
+				}
+				opponentHeads[letter] = coord
+			case isOpponent(symbol):
+				if _, ok := opponentBodies[symbol]; !ok {
+					opponentBodies[symbol] = make(map[Coord]bool)
+				}
+				opponentBodies[symbol][coord] = true
+			default:
+				return GameState{}, fmt.Errorf("unexpected symbol '%c' at %s", symbol, coord)
+			}
+		}
+	}
+
+	// Trace your body from the head, preferring the next number in sequence
+	if head == nil {
+		return GameState{}, fmt.Errorf("found no head '%c'", SymbolHead)
+	}
+	squares := make(map[Coord]bool)
+	for coord := range body {
+		squares[coord] = true
+	}
+	path, ok := trace(*head, squares, func(segment int, coord Coord) bool {
+		return body[coord] == bodySymbol(segment)
+	})
+	if !ok {
+		return GameState{}, fmt.Errorf("unable to trace your body from the head at %s", *head)
+	}
+	state.You = newSnake(YouID, path)
+	state.Board.Snakes = append(state.Board.Snakes, state.You)
+
+	// Trace each opponent's body from its head
+	letters := make([]rune, 0)
+	for letter := range opponentHeads {
+		letters = append(letters, letter)
+	}
+	for letter := range opponentBodies {
+		if _, ok := opponentHeads[letter]; !ok {
+			return GameState{}, fmt.Errorf("found no head '%c' for the body of '%c'", unicode.ToUpper(letter), letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	for _, letter := range letters {
+		path, ok := trace(opponentHeads[letter], opponentBodies[letter], nil)
+		if !ok {
+			return GameState{}, fmt.Errorf("unable to trace the body of '%c' from its head at %s", letter, opponentHeads[letter])
+		}
+		state.Board.Snakes = append(state.Board.Snakes, newSnake(string(letter), path))
+	}
+	return state, nil
+}
+
+// MustParseBoard Returns the game state drawn by a diagram of the board and panics if the diagram
+// is invalid. It is intended for tests; see ParseBoard.
+func MustParseBoard(diagram string) GameState {
+	state, err := ParseBoard(diagram)
+	if err != nil {
+		panic(err)
+	}
+	return state
+}
+
+func isOpponent(symbol rune) bool {
+	return strings.ContainsRune(opponentSymbols, symbol)
+}
+
+func newSnake(id string, body []Coord) Snake {
+	return Snake{
+		ID:     id,
+		Name:   id,
+		Health: 100,
+		Body:   body,
+		Head:   body[0],
+		Length: len(body),
+	}
+}
+
+// parseRows Returns the symbols in each row of the diagram with any coordinate labels removed.
+func parseRows(diagram string) ([][]rune, error) {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(diagram, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("diagram is empty")
+	}
+
+	// Remove the labels drawn along the axes
+	if labelled, ok := removeLabels(lines); ok {
+		lines = labelled
+	}
+
+	rows := make([][]rune, 0, len(lines))
+	for _, line := range lines {
+		row := []rune(strings.Join(strings.Fields(line), ""))
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("expected %d square(s) in row '%s'", len(rows[0]), line)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// removeLabels Returns the rows without the labels drawn along the axes by Render. Returns
+// false if the diagram is not labelled.
+func removeLabels(lines []string) ([]string, bool) {
+	if len(lines) < 2 {
+		return nil, false
+	}
+
+	// The last line labels the X coordinate of each column
+	axis := strings.Fields(lines[len(lines)-1])
+	for i, field := range axis {
+		if field != strconv.Itoa(i%10) {
+			return nil, false
+		}
+	}
+
+	// Each row starts with its Y coordinate
+	rows := make([]string, 0, len(lines)-1)
+	for _, line := range lines[:len(lines)-1] {
+		fields := strings.Fields(line)
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			return nil, false
+		}
+		row := strings.Join(fields[1:], "")
+		if len([]rune(row)) != len(axis) {
+			return nil, false
+		}
+		rows = append(rows, row)
+	}
+	return rows, true
+}
+
+// trace Returns a path from the head that covers every square of a body. Each segment prefers
+// squares accepted by the preference; a nil preference accepts any square.
+func trace(head Coord, body map[Coord]bool, prefer func(segment int, coord Coord) bool) ([]Coord, bool) {
+	path := []Coord{head}
+	visited := make(map[Coord]bool)
+	var visit func(curr Coord) bool
+	visit = func(curr Coord) bool {
+		if len(path) == len(body)+1 {
+			return true
+		}
+		neighbors := []Coord{curr.Up(), curr.Down(), curr.Left(), curr.Right()}
+		sort.SliceStable(neighbors, func(i, j int) bool {
+			return prefer != nil && prefer(len(path), neighbors[i]) && !prefer(len(path), neighbors[j])
+		})
+		for _, next := range neighbors {
+			if !body[next] || visited[next] {
+				continue
+			}
+			visited[next] = true
+			path = append(path, next)
+			if visit(next) {
+				return true
+			}
+			visited[next] = false
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	return path, visit(head)
+}
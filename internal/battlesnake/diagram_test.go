@@ -0,0 +1,89 @@
+package battlesnake
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ParseBoard(t *testing.T) {
+	state, err := ParseBoard(`
+		* . . . .
+		. . . A .
+		. H . a #
+		2 1 . a #
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 5, state.Board.Width)
+	require.Equal(t, 4, state.Board.Height)
+	require.Equal(t, []Coord{{0, 3}}, state.Board.Food)
+	require.ElementsMatch(t, []Coord{{4, 0}, {4, 1}}, state.Board.Hazards)
+
+	require.Equal(t, YouID, state.You.ID)
+	require.Equal(t, Coord{1, 1}, state.You.Head)
+	require.Equal(t, Body{{1, 1}, {1, 0}, {0, 0}}, state.You.Body)
+	require.Equal(t, 3, state.You.Length)
+	require.Equal(t, 100, state.You.Health)
+
+	require.Len(t, state.Board.Snakes, 2)
+	require.Equal(t, state.You, state.Board.Snakes[0])
+	opponent := state.Board.Snakes[1]
+	require.Equal(t, "a", opponent.ID)
+	require.Equal(t, Coord{3, 2}, opponent.Head)
+	require.Equal(t, Body{{3, 2}, {3, 1}, {3, 0}}, opponent.Body)
+	require.Equal(t, 3, opponent.Length)
+}
+
+func Test_ParseBoard_Unspaced(t *testing.T) {
+	state, err := ParseBoard(`
+		...
+		H1.
+		32.
+	`)
+	require.NoError(t, err)
+	require.Equal(t, Body{{0, 1}, {1, 1}, {1, 0}, {0, 0}}, state.You.Body)
+}
+
+func Test_ParseBoard_FollowsNumbering(t *testing.T) {
+	// The body could be traced either way around, the numbers decide which
+	state, err := ParseBoard(`
+		3 2 .
+		H 1 .
+	`)
+	require.NoError(t, err)
+	require.Equal(t, Body{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, state.You.Body)
+}
+
+func Test_ParseBoard_RoundTrip(t *testing.T) {
+	diagram := "" +
+		"3 * . . . .\n" +
+		"2 . . . A .\n" +
+		"1 . H . a #\n" +
+		"0 2 1 . a #\n" +
+		"  0 1 2 3 4\n"
+	state, err := ParseBoard(diagram)
+	require.NoError(t, err)
+	require.Equal(t, diagram, Render(state))
+}
+
+func Test_ParseBoard_Invalid(t *testing.T) {
+	_, err := ParseBoard("")
+	require.ErrorContains(t, err, "empty")
+
+	_, err = ParseBoard(". . .\n. H")
+	require.ErrorContains(t, err, "expected 3 square(s)")
+
+	_, err = ParseBoard(". . .\n. 1 .")
+	require.ErrorContains(t, err, "no head")
+
+	_, err = ParseBoard("H . .\n. . H")
+	require.ErrorContains(t, err, "more than one head")
+
+	_, err = ParseBoard("H . .\n. . 1")
+	require.ErrorContains(t, err, "unable to trace your body")
+
+	_, err = ParseBoard("H a .\n. . .")
+	require.ErrorContains(t, err, "no head 'A'")
+
+	_, err = ParseBoard("H ? .\n. . .")
+	require.ErrorContains(t, err, "unexpected symbol '?'")
+}
@@ -0,0 +1,127 @@
+package battlesnake
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventBuffer The number of events buffered for each subscriber. Events are dropped for
+// subscribers that fall this far behind so that a slow dashboard cannot delay a move.
+const eventBuffer = 64
+
+// keepAliveInterval How often a comment is sent to keep idle event streams open.
+const keepAliveInterval = 15 * time.Second
+
+// Broadcaster Streams each record to any number of subscribers as server-sent events.
+type Broadcaster struct {
+	mutex       sync.Mutex
+	subscribers map[chan Record]bool
+	closed      bool
+	logger      zerolog.Logger
+}
+
+func NewBroadcaster(logger zerolog.Logger) *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan Record]bool),
+		logger:      logger,
+	}
+}
+
+// Subscribe Returns a channel of published records along with a function that ends the subscription.
+func (b *Broadcaster) Subscribe() (<-chan Record, func()) {
+	events := make(chan Record, eventBuffer)
+	b.mutex.Lock()
+	if b.closed {
+		close(events)
+	} else {
+		b.subscribers[events] = true
+	}
+	b.mutex.Unlock()
+	return events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if b.subscribers[events] {
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Close Ends every subscription, now and in the future.
+func (b *Broadcaster) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	for events := range b.subscribers {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
+
+// Publish Sends a record to every subscriber without waiting on any of them.
+func (b *Broadcaster) Publish(record Record) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for events := range b.subscribers {
+		select {
+		case events <- record:
+		default:
+			// The subscriber is too far behind
+		}
+	}
+}
+
+// HandleEvents Streams each record as a server-sent event named after the record type. The
+// stream can be limited to a single game with the 'game' query parameter.
+func (b *Broadcaster) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	gameID := r.URL.Query().Get("game")
+	events, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, err := fmt.Fprint(w, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case record, ok := <-events:
+			if !ok {
+				return
+			}
+			if len(gameID) > 0 && record.State.Game.ID != gameID {
+				continue
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				b.logger.Error().Err(err).Msg("Failed to encode event")
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", record.Type, data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
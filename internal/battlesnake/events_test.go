@@ -0,0 +1,112 @@
+package battlesnake
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Broadcaster_Publish(t *testing.T) {
+	broadcaster := NewBroadcaster(zerolog.Nop())
+	first, unsubscribeFirst := broadcaster.Subscribe()
+	second, unsubscribeSecond := broadcaster.Subscribe()
+	defer unsubscribeSecond()
+
+	broadcaster.Publish(Record{Type: RecordMove})
+	require.Equal(t, RecordMove, (<-first).Type)
+	require.Equal(t, RecordMove, (<-second).Type)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	broadcaster.Publish(Record{Type: RecordEnd})
+	_, ok := <-first
+	require.False(t, ok)
+	require.Equal(t, RecordEnd, (<-second).Type)
+}
+
+func Test_Broadcaster_Publish_SlowSubscriber(t *testing.T) {
+	broadcaster := NewBroadcaster(zerolog.Nop())
+	events, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	// Publishing never blocks on a subscriber that is not reading
+	for i := 0; i < eventBuffer*2; i++ {
+		broadcaster.Publish(Record{Type: RecordMove})
+	}
+	require.Len(t, events, eventBuffer)
+}
+
+func Test_SnakeServer_HandleEvents(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/events":
+			server.HandleEvents(w, r)
+		case "/move":
+			server.HandleMove(w, r)
+		}
+	}))
+	defer live.Close()
+
+	response, err := live.Client().Get(live.URL + "/events?game=game-1")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	// A move in another game is filtered out of the stream
+	other := strings.Replace(testState, "game-1", "game-2", 1)
+	for _, state := range []string{other, testState} {
+		moved, err := live.Client().Post(live.URL+"/move", "application/json", strings.NewReader(state))
+		require.NoError(t, err)
+		moved.Body.Close()
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for an event")
+			return ""
+		}
+	}
+
+	require.Equal(t, "event: move", next())
+	data := next()
+	require.True(t, strings.HasPrefix(data, "data: "))
+	var record Record
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &record))
+	require.Equal(t, "game-1", record.State.Game.ID)
+	require.Equal(t, 3, record.State.Turn)
+	require.Equal(t, UP, record.Response.Move)
+	require.Equal(t, 10, record.Scores["always-up"][UP])
+	require.Equal(t, UP, record.Explanation.Chosen)
+}
+
+func Test_Broadcaster_Close(t *testing.T) {
+	broadcaster := NewBroadcaster(zerolog.Nop())
+	events, unsubscribe := broadcaster.Subscribe()
+	broadcaster.Close()
+	_, ok := <-events
+	require.False(t, ok)
+	unsubscribe()
+
+	// Subscriptions after closing end immediately
+	late, _ := broadcaster.Subscribe()
+	_, ok = <-late
+	require.False(t, ok)
+}
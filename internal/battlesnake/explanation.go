@@ -0,0 +1,38 @@
+package battlesnake
+
+// Explanation Describes how a snake chose its move on one turn.
+type Explanation struct {
+	Strategies []StrategyExplanation `json:"strategies"`
+	Ranking    []RankedMove          `json:"ranking"`            // the safe moves from best to worst
+	Chosen     Move                  `json:"chosen"`             // the move that was made
+	TieBreak   string                `json:"tieBreak,omitempty"` // how a tie for the best score was broken
+}
+
+// StrategyExplanation Describes what a single strategy thought of each move.
+type StrategyExplanation struct {
+	Name          string       `json:"name"`
+	Contributions map[Move]int `json:"contributions,omitempty"` // the score added to each safe move
+	Vetoes        []Move       `json:"vetoes,omitempty"`        // the moves marked unsafe
+	Reasons       []string     `json:"reasons,omitempty"`
+}
+
+// RankedMove A safe move and its final score.
+type RankedMove struct {
+	Move  Move `json:"move"`
+	Score int  `json:"score"`
+}
+
+// Scores Returns the score that each strategy added to each move.
+func (e *Explanation) Scores() map[string]map[Move]int {
+	scores := make(map[string]map[Move]int)
+	for _, strategy := range e.Strategies {
+		if len(strategy.Contributions) == 0 {
+			continue
+		}
+		scores[strategy.Name] = make(map[Move]int)
+		for move, score := range strategy.Contributions {
+			scores[strategy.Name][move] = score
+		}
+	}
+	return scores
+}
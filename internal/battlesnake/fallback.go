@@ -0,0 +1,54 @@
+package battlesnake
+
+// fallbackOrder The order in which fallback moves that are equally safe are preferred.
+var fallbackOrder = []Move{UP, DOWN, LEFT, RIGHT}
+
+// FallbackMove Chooses a move using nothing but the game state. It is played when the snake itself
+// fails, so it is kept simple enough that it cannot fail too. The safest move is preferred; a move
+// that stays on the board and avoids every body, then one that also avoids hazards, then one that
+// also avoids squares a snake at least as long could move to.
+func FallbackMove(state GameState) Move {
+	grid := NewGrid(state)
+	if grid.Width <= 0 || grid.Height <= 0 {
+		return DOWN
+	}
+	blocked := make(map[Coord]bool)
+	contested := make(map[Coord]bool)
+	for _, snake := range state.Board.Snakes {
+		for i, part := range snake.Body {
+			isTail := i > 0 && i == len(snake.Body)-1
+			if isTail && part != snake.Body[i-1] {
+				continue // the tail moves out of the way unless the snake just ate
+			}
+			blocked[part] = true
+		}
+		if snake.ID != state.You.ID && snake.Length >= state.You.Length {
+			for _, move := range fallbackOrder {
+				contested[grid.Move(snake.Head, move)] = true
+			}
+		}
+	}
+	hazards := make(map[Coord]bool)
+	for _, hazard := range state.Board.Hazards {
+		hazards[hazard] = true
+	}
+
+	best, bestSafety := DOWN, 0
+	for _, move := range fallbackOrder {
+		next := grid.Move(state.You.Head, move)
+		safety := 0
+		if grid.InBounds(next) && !blocked[next] {
+			safety = 1
+			if !hazards[next] {
+				safety = 2
+				if !contested[next] {
+					safety = 3
+				}
+			}
+		}
+		if safety > bestSafety {
+			best, bestSafety = move, safety
+		}
+	}
+	return best
+}
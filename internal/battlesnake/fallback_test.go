@@ -0,0 +1,96 @@
+package battlesnake
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_FallbackMove(t *testing.T) {
+	tests := map[string]struct {
+		diagram string
+		want    Move
+	}{
+		"stays on the board": {
+			diagram: `
+				H 1 2
+				. . .
+			`,
+			want: DOWN,
+		},
+		"avoids bodies": {
+			diagram: `
+				a a A .
+				. H . .
+				. 1 2 .
+			`,
+			want: LEFT,
+		},
+		"follows its own tail": {
+			diagram: `
+				H 1 .
+				3 2 .
+			`,
+			want: DOWN,
+		},
+		"avoids hazards": {
+			diagram: `
+				# . .
+				H 1 2
+				. . .
+			`,
+			want: DOWN,
+		},
+		"avoids heads of longer snakes": {
+			diagram: `
+				. . . .
+				. A a a
+				H 1 2 .
+				. . . .
+			`,
+			want: DOWN,
+		},
+		"prefers a hazard over a body": {
+			diagram: `
+				# . .
+				H A a
+				1 2 .
+			`,
+			want: UP,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := MustParseBoard(test.diagram)
+			require.Equal(t, test.want, FallbackMove(state), Render(state))
+		})
+	}
+}
+
+func Test_FallbackMove_TailAfterEating(t *testing.T) {
+	// The tail stays put on the turn after eating
+	state := MustParseBoard(`
+		H 1 .
+		3 2 .
+	`)
+	state.You.Body = append(state.You.Body, state.You.Body[3])
+	state.Board.Snakes[0] = state.You
+	require.Equal(t, DOWN, FallbackMove(state))
+
+	state.Board.Width = 2
+	state.Board.Height = 2
+	require.Equal(t, DOWN, FallbackMove(state), "there are no safe moves")
+}
+
+func Test_FallbackMove_Wrapped(t *testing.T) {
+	state := MustParseBoard(`
+		H 1 2
+		. 4 3
+	`)
+	state.Game.Ruleset.Name = RulesetWrapped
+	require.Equal(t, UP, FallbackMove(state))
+}
+
+func Test_FallbackMove_Empty(t *testing.T) {
+	require.Equal(t, DOWN, FallbackMove(GameState{}))
+	require.Equal(t, UP, FallbackMove(GameState{Board: Board{Width: 1, Height: 2}}))
+}
@@ -0,0 +1,113 @@
+package battlesnake
+
+// Grid Performs coordinate operations that are aware of the board's
+// dimensions. In the wrapped ruleset, coordinates that move off one
+// edge of the board reappear on the opposite edge.
+type Grid struct {
+	Width   int
+	Height  int
+	Wrapped bool
+}
+
+func NewGrid(state GameState) Grid {
+	return Grid{
+		Width:   state.Board.Width,
+		Height:  state.Board.Height,
+		Wrapped: state.Game.Ruleset.IsWrapped(),
+	}
+}
+
+func (g Grid) Move(c Coord, move Move) Coord {
+	return g.Wrap(c.Move(move))
+}
+
+func (g Grid) Right(c Coord) Coord {
+	return g.Wrap(c.Right())
+}
+
+func (g Grid) Left(c Coord) Coord {
+	return g.Wrap(c.Left())
+}
+
+func (g Grid) Up(c Coord) Coord {
+	return g.Wrap(c.Up())
+}
+
+func (g Grid) Down(c Coord) Coord {
+	return g.Wrap(c.Down())
+}
+
+// Wrap Returns the coordinate wrapped onto the board. Coordinates are
+// returned unchanged unless the board wraps.
+func (g Grid) Wrap(c Coord) Coord {
+	if !g.Wrapped || g.Width <= 0 || g.Height <= 0 {
+		return c
+	}
+	return Coord{
+		X: mod(c.X, g.Width),
+		Y: mod(c.Y, g.Height),
+	}
+}
+
+// InBounds Returns true if the coordinate is on the board.
+func (g Grid) InBounds(c Coord) bool {
+	return c.X >= 0 && c.X < g.Width && c.Y >= 0 && c.Y < g.Height
+}
+
+// Delta Returns the shortest X and Y offsets from one coordinate to another.
+// On a wrapped board the shortest path may cross an edge.
+func (g Grid) Delta(from Coord, to Coord) (int, int) {
+	dx := to.X - from.X
+	dy := to.Y - from.Y
+	if g.Wrapped {
+		dx = shortest(dx, g.Width)
+		dy = shortest(dy, g.Height)
+	}
+	return dx, dy
+}
+
+// DistanceTo Returns the Manhattan Distance between two coordinates, which
+// may cross an edge on a wrapped board.
+func (g Grid) DistanceTo(from Coord, to Coord) int {
+	dx, dy := g.Delta(from, to)
+	return abs(dx) + abs(dy)
+}
+
+// MaxDistance Returns the greatest distance possible between two coordinates.
+func (g Grid) MaxDistance() int {
+	if g.Wrapped {
+		return g.Width/2 + g.Height/2
+	}
+	return g.Width + g.Height - 2
+}
+
+func (g Grid) MoveTo(from Coord, to Coord) Move {
+	dx, dy := g.Delta(from, to)
+	if abs(dx) > abs(dy) {
+		if dx > 0 {
+			return RIGHT
+		}
+		return LEFT
+	} else {
+		if dy > 0 {
+			return UP
+		}
+		return DOWN
+	}
+}
+
+func mod(x int, n int) int {
+	return ((x % n) + n) % n
+}
+
+// shortest Returns the shortest offset equivalent to delta on a wrapped axis of the given size.
+func shortest(delta int, size int) int {
+	if size <= 0 {
+		return delta
+	}
+	delta = mod(delta, size)
+	if delta > size/2 {
+		delta -= size
+	}
+	return delta
+}
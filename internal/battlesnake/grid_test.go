@@ -0,0 +1,46 @@
+package battlesnake
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Grid_Wrap(t *testing.T) {
+	grid := Grid{Width: 5, Height: 5, Wrapped: true}
+	require.EqualValues(t, Coord{0, 2}, grid.Right(Coord{4, 2}))
+	require.EqualValues(t, Coord{4, 2}, grid.Left(Coord{0, 2}))
+	require.EqualValues(t, Coord{2, 0}, grid.Up(Coord{2, 4}))
+	require.EqualValues(t, Coord{2, 4}, grid.Down(Coord{2, 0}))
+	require.EqualValues(t, Coord{2, 2}, grid.Move(Coord{2, 1}, UP))
+}
+
+func Test_Grid_NotWrapped(t *testing.T) {
+	grid := Grid{Width: 5, Height: 5}
+	require.EqualValues(t, Coord{5, 2}, grid.Right(Coord{4, 2}))
+	require.EqualValues(t, Coord{-1, 2}, grid.Left(Coord{0, 2}))
+	require.False(t, grid.InBounds(grid.Right(Coord{4, 2})))
+	require.True(t, grid.InBounds(Coord{4, 4}))
+}
+
+func Test_Grid_DistanceTo(t *testing.T) {
+	wrapped := Grid{Width: 11, Height: 11, Wrapped: true}
+	require.Equal(t, 2, wrapped.DistanceTo(Coord{0, 0}, Coord{10, 10}))
+	require.Equal(t, 10, wrapped.DistanceTo(Coord{0, 0}, Coord{5, 5}))
+	require.Equal(t, 10, wrapped.MaxDistance())
+
+	bounded := Grid{Width: 11, Height: 11}
+	require.Equal(t, 20, bounded.DistanceTo(Coord{0, 0}, Coord{10, 10}))
+	require.Equal(t, 20, bounded.MaxDistance())
+}
+
+func Test_Grid_MoveTo(t *testing.T) {
+	wrapped := Grid{Width: 11, Height: 11, Wrapped: true}
+	require.Equal(t, LEFT, wrapped.MoveTo(Coord{0, 5}, Coord{10, 5}))
+	require.Equal(t, RIGHT, wrapped.MoveTo(Coord{10, 5}, Coord{0, 5}))
+	require.Equal(t, DOWN, wrapped.MoveTo(Coord{5, 0}, Coord{5, 9}))
+	require.Equal(t, UP, wrapped.MoveTo(Coord{5, 9}, Coord{5, 0}))
+
+	bounded := Grid{Width: 11, Height: 11}
+	require.Equal(t, RIGHT, bounded.MoveTo(Coord{0, 5}, Coord{10, 5}))
+	require.Equal(t, UP, bounded.MoveTo(Coord{5, 0}, Coord{5, 9}))
+}
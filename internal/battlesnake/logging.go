@@ -0,0 +1,68 @@
+package battlesnake
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"io"
+	"strings"
+)
+
+// Log formats
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// LogConfig Configures what the server logs and how.
+type LogConfig struct {
+	Level       string // the lowest level logged, like 'debug' or 'warn'; defaults to 'info'
+	Format      string // either 'console' or 'json'; defaults to 'console'
+	SampleTurns int    // logs one in every N turns of a game; zero or one logs every turn
+}
+
+// Logging Where and how much the server logs.
+type Logging struct {
+	Logger      zerolog.Logger
+	SampleTurns int
+}
+
+// NewLogging Creates the loggers described by the configuration, writing to the given output.
+func NewLogging(config LogConfig, out io.Writer) (Logging, error) {
+	level := zerolog.InfoLevel
+	if len(config.Level) > 0 {
+		parsed, err := zerolog.ParseLevel(strings.ToLower(config.Level))
+		if err != nil {
+			return Logging{}, fmt.Errorf("unknown log level '%s'", config.Level)
+		}
+		level = parsed
+	}
+	switch strings.ToLower(config.Format) {
+	case "", LogFormatConsole:
+		out = zerolog.ConsoleWriter{Out: out}
+	case LogFormatJSON:
+	default:
+		return Logging{}, fmt.Errorf("unknown log format '%s'; expected %s or %s", config.Format, LogFormatConsole, LogFormatJSON)
+	}
+	if config.SampleTurns < 0 {
+		return Logging{}, fmt.Errorf("cannot sample every %d turns", config.SampleTurns)
+	}
+	return Logging{
+		Logger:      zerolog.New(out).Level(level).With().Timestamp().Logger(),
+		SampleTurns: config.SampleTurns,
+	}, nil
+}
+
+// sampled Returns true if the turn should be logged.
+func (l Logging) sampled(turn int) bool {
+	return l.SampleTurns <= 1 || turn%l.SampleTurns == 0
+}
+
+// forRequest Returns a logger with the fields that identify a request.
+func forRequest(logger zerolog.Logger, endpoint string, snakeName string, state GameState) zerolog.Logger {
+	return logger.With().
+		Str("endpoint", endpoint).
+		Str("snake", snakeName).
+		Str("game-id", state.Game.ID).
+		Int("turn", state.Turn).
+		Logger()
+}
@@ -0,0 +1,81 @@
+package battlesnake
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_NewLogging(t *testing.T) {
+	var out bytes.Buffer
+	logging, err := NewLogging(LogConfig{Level: "WARN", Format: LogFormatJSON}, &out)
+	require.NoError(t, err)
+	logging.Logger.Info().Msg("hidden")
+	logging.Logger.Warn().Msg("shown")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "warn", line["level"])
+	require.Equal(t, "shown", line["message"])
+	require.Contains(t, line, "time")
+}
+
+func Test_NewLogging_Console(t *testing.T) {
+	var out bytes.Buffer
+	logging, err := NewLogging(LogConfig{}, &out)
+	require.NoError(t, err)
+	logging.Logger.Info().Msg("hello")
+	require.Contains(t, out.String(), "INF")
+	require.Contains(t, out.String(), "hello")
+	require.False(t, json.Valid(out.Bytes()))
+}
+
+func Test_NewLogging_Invalid(t *testing.T) {
+	_, err := NewLogging(LogConfig{Level: "loud"}, &bytes.Buffer{})
+	require.EqualError(t, err, "unknown log level 'loud'")
+	_, err = NewLogging(LogConfig{Format: "xml"}, &bytes.Buffer{})
+	require.EqualError(t, err, "unknown log format 'xml'; expected console or json")
+	_, err = NewLogging(LogConfig{SampleTurns: -1}, &bytes.Buffer{})
+	require.EqualError(t, err, "cannot sample every -1 turns")
+}
+
+func Test_Logging_sampled(t *testing.T) {
+	require.True(t, Logging{}.sampled(7))
+	require.True(t, Logging{SampleTurns: 1}.sampled(7))
+	require.True(t, Logging{SampleTurns: 5}.sampled(0))
+	require.False(t, Logging{SampleTurns: 5}.sampled(7))
+	require.True(t, Logging{SampleTurns: 5}.sampled(10))
+}
+
+func Test_SnakeServer_LogsRequests(t *testing.T) {
+	var out bytes.Buffer
+	logging, err := NewLogging(LogConfig{Format: LogFormatJSON, SampleTurns: 2}, &out)
+	require.NoError(t, err)
+	server := NewSnakeServer(&testSnake{}, nil, logging)
+
+	// Turn 3 is not sampled
+	server.HandleMove(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(testState)))
+	require.Empty(t, out.String())
+
+	turn4 := strings.Replace(testState, `"turn": 3`, `"turn": 4`, 1)
+	server.HandleMove(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(turn4)))
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "Moved", line["message"])
+	require.Equal(t, "/move", line["endpoint"])
+	require.Equal(t, "test", line["snake"])
+	require.Equal(t, "game-1", line["game-id"])
+	require.Equal(t, 4.0, line["turn"])
+	require.Contains(t, line, "latency-ms")
+
+	// Errors are never sampled
+	out.Reset()
+	server.HandleMove(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/move", strings.NewReader("{")))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	require.Equal(t, "error", line["level"])
+	require.Equal(t, "Failed to decode json", line["message"])
+}
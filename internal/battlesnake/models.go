@@ -38,6 +38,7 @@ type Snake struct {
 	Length         int            `json:"length"`
 	Latency        string         `json:"latency"`
 	Shout          string         `json:"shout"`
+	Squad          string         `json:"squad"`
 	Customizations Customizations `json:"customizations"`
 }
 
@@ -76,10 +77,56 @@ type Ruleset struct {
 	Settings RulesetSettings `json:"settings"`
 }
 
+// Ruleset names
+// https://docs.battlesnake.com/guides/game/rules
+const (
+	RulesetStandard    = "standard"
+	RulesetSolo        = "solo"
+	RulesetRoyale      = "royale"
+	RulesetSquad       = "squad"
+	RulesetConstrictor = "constrictor"
+	RulesetWrapped     = "wrapped"
+)
+
+// IsWrapped Returns true if snakes wrap around the edges of the board.
+func (r Ruleset) IsWrapped() bool {
+	return r.Name == RulesetWrapped
+}
+
+// IsConstrictor Returns true if snakes grow every turn and food does not exist.
+func (r Ruleset) IsConstrictor() bool {
+	return r.Name == RulesetConstrictor
+}
+
+// IsRoyale Returns true if hazards shrink the board over time.
+func (r Ruleset) IsRoyale() bool {
+	return r.Name == RulesetRoyale
+}
+
+// IsSquad Returns true if snakes play on teams.
+func (r Ruleset) IsSquad() bool {
+	return r.Name == RulesetSquad
+}
+
 type RulesetSettings struct {
-	FoodSpawnChance     int `json:"foodSpawnChance"`
-	MinimumFood         int `json:"minimumFood"`
-	HazardDamagePerTurn int `json:"hazardDamagePerTurn"`
+	FoodSpawnChance     int            `json:"foodSpawnChance"`
+	MinimumFood         int            `json:"minimumFood"`
+	HazardDamagePerTurn int            `json:"hazardDamagePerTurn"`
+	HazardMap           string         `json:"hazardMap"`
+	HazardMapAuthor     string         `json:"hazardMapAuthor"`
+	Royale              RoyaleSettings `json:"royale"`
+	Squad               SquadSettings  `json:"squad"`
+}
+
+type RoyaleSettings struct {
+	ShrinkEveryNTurns int `json:"shrinkEveryNTurns"`
+}
+
+type SquadSettings struct {
+	AllowBodyCollisions bool `json:"allowBodyCollisions"`
+	SharedElimination   bool `json:"sharedElimination"`
+	SharedHealth        bool `json:"sharedHealth"`
+	SharedLength        bool `json:"sharedLength"`
 }
 
 // Response Objects
@@ -96,3 +143,25 @@ type MoveResponse struct {
 	Move  Move   `json:"move"`
 	Shout string `json:"shout"`
 }
+
+// ErrorResponse Describes why a request failed.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// EvaluateResponse Describes how a snake would move given any game state.
+type EvaluateResponse struct {
+	Move        Move                    `json:"move"`
+	Scores      map[string]map[Move]int `json:"scores"`
+	Explanation *Explanation            `json:"explanation,omitempty"`
+	Board       string                  `json:"board"`
+	Panic       string                  `json:"panic,omitempty"` // why the snake failed, leaving the fallback move
+}
+
+// Evaluation The move chosen by a snake along with the score each of its strategies gave to each move.
+type Evaluation struct {
+	Response    MoveResponse            `json:"response"`
+	Scores      map[string]map[Move]int `json:"scores"`
+	Explanation *Explanation            `json:"explanation,omitempty"`
+	Panic       string                  `json:"panic,omitempty"` // why the snake failed, leaving the fallback move
+}
@@ -0,0 +1,43 @@
+package battlesnake
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_RulesetSettings_Decode(t *testing.T) {
+	raw := `{
+		"name": "royale",
+		"version": "v1.2.3",
+		"settings": {
+			"foodSpawnChance": 25,
+			"minimumFood": 1,
+			"hazardDamagePerTurn": 14,
+			"hazardMap": "hz_spiral",
+			"hazardMapAuthor": "altersaddle",
+			"royale": {
+				"shrinkEveryNTurns": 5
+			},
+			"squad": {
+				"allowBodyCollisions": true,
+				"sharedElimination": true,
+				"sharedHealth": false,
+				"sharedLength": true
+			}
+		}
+	}`
+	var ruleset Ruleset
+	require.NoError(t, json.Unmarshal([]byte(raw), &ruleset))
+	require.True(t, ruleset.IsRoyale())
+	require.Equal(t, 14, ruleset.Settings.HazardDamagePerTurn)
+	require.Equal(t, "hz_spiral", ruleset.Settings.HazardMap)
+	require.Equal(t, "altersaddle", ruleset.Settings.HazardMapAuthor)
+	require.Equal(t, 5, ruleset.Settings.Royale.ShrinkEveryNTurns)
+	require.Equal(t, SquadSettings{
+		AllowBodyCollisions: true,
+		SharedElimination:   true,
+		SharedHealth:        false,
+		SharedLength:        true,
+	}, ruleset.Settings.Squad)
+}
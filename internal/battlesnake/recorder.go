@@ -0,0 +1,269 @@
+package battlesnake
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record types
+const (
+	RecordStart = "start"
+	RecordMove  = "move"
+	RecordEnd   = "end"
+)
+
+// Record A request received by the snake server along with the response that was sent.
+type Record struct {
+	Type        string                  `json:"type"`
+	Time        time.Time               `json:"time"`
+	State       GameState               `json:"state"`
+	Response    *MoveResponse           `json:"response,omitempty"`
+	Scores      map[string]map[Move]int `json:"scores,omitempty"`
+	Explanation *Explanation            `json:"explanation,omitempty"`
+	LatencyMS   int64                   `json:"latencyMs"`
+}
+
+// Recorder Persists every record of a game as newline-delimited JSON in a file named after the game ID.
+type Recorder struct {
+	dir       string
+	mutex     sync.Mutex               // guards the maps below, but not the files
+	games     map[string]*sync.Mutex   // by path, so each game's file is written by one request at a time
+	summaries map[string]cachedSummary // by path, so the games can be listed without reading them
+}
+
+// cachedSummary The summary of a recorded game as of when its file was the given size.
+type cachedSummary struct {
+	summary GameSummary
+	size    int64
+}
+
+func NewRecorder(dir string) (*Recorder, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &Recorder{
+		dir:       dir,
+		games:     make(map[string]*sync.Mutex),
+		summaries: make(map[string]cachedSummary),
+	}, nil
+}
+
+// lock Locks the file of one game, leaving other games free to be recorded at the same time.
+// Returns the function that unlocks it.
+func (r *Recorder) lock(path string) func() {
+	r.mutex.Lock()
+	game, ok := r.games[path]
+	if !ok {
+		game = &sync.Mutex{}
+		r.games[path] = game
+	}
+	r.mutex.Unlock()
+	game.Lock()
+	return game.Unlock
+}
+
+// Path Returns the path to the file recording a game.
+func (r *Recorder) Path(gameID string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(gameID)
+	if name == "" {
+		name = "unknown"
+	}
+	return filepath.Join(r.dir, name+".jsonl")
+}
+
+// Record Appends a record to the file of the game that it belongs to.
+func (r *Recorder) Record(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	// Records for the same game may arrive concurrently
+	path := r.Path(record.State.Game.ID)
+	unlock := r.lock(path)
+	defer unlock()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	// The record just written is the last in the file, so it is all the summary needs
+	info, err := file.Stat()
+	if err == nil {
+		r.cache(path, cachedSummary{summary: summarize(record, info), size: info.Size()})
+	}
+	return nil
+}
+
+func (r *Recorder) cache(path string, cached cachedSummary) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.summaries[path] = cached
+}
+
+// GameSummary Describes a recorded game.
+type GameSummary struct {
+	ID       string    `json:"id"`
+	Snake    string    `json:"snake"`
+	Turns    int       `json:"turns"`
+	Active   bool      `json:"active"` // the game has not yet ended
+	Result   string    `json:"result,omitempty"`
+	Modified time.Time `json:"modified"`
+}
+
+// Games Returns a summary of each recorded game, most recently played first. A game is summarized
+// by its last record, which is remembered as it is recorded or else read from the end of its file.
+func (r *Recorder) Games() ([]GameSummary, error) {
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	games := make([]GameSummary, 0, len(paths))
+	for _, path := range paths {
+		if summary, ok := r.summary(path); ok {
+			games = append(games, summary)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Modified.After(games[j].Modified)
+	})
+	return games, nil
+}
+
+// summary Returns the summary of a recorded game, reading its last record only if the game has been
+// written since it was last summarized. Returns false if the file is not a recorded game.
+func (r *Recorder) summary(path string) (GameSummary, bool) {
+	unlock := r.lock(path)
+	defer unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return GameSummary{}, false // The file may have been removed
+	}
+	r.mutex.Lock()
+	cached, ok := r.summaries[path]
+	r.mutex.Unlock()
+	if ok && cached.size == info.Size() {
+		return cached.summary, true
+	}
+	last, err := readLastRecord(path)
+	if err != nil {
+		return GameSummary{}, false
+	}
+	cached = cachedSummary{summary: summarize(last, info), size: info.Size()}
+	r.cache(path, cached)
+	return cached.summary, true
+}
+
+// Read Returns all the records of a game.
+func (r *Recorder) Read(gameID string) ([]Record, error) {
+	return ReadRecords(r.Path(gameID))
+}
+
+// summarize Returns the summary of a recorded game from its last record.
+func summarize(last Record, info os.FileInfo) GameSummary {
+	summary := GameSummary{
+		ID:       last.State.Game.ID,
+		Snake:    last.State.You.Name,
+		Turns:    last.State.Turn,
+		Active:   last.Type != RecordEnd,
+		Modified: info.ModTime(),
+	}
+	if !summary.Active {
+		summary.Result = result(last.State)
+	}
+	return summary
+}
+
+// result Returns whether you won, lost or drew a game that has ended.
+func result(state GameState) string {
+	if len(state.Board.Snakes) == 0 {
+		return "draw"
+	}
+	if state.Board.Snakes[0].ID == state.You.ID {
+		return "won"
+	}
+	return "lost"
+}
+
+// ReadRecords Reads all the records from a recorded game.
+func ReadRecords(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record Record
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// readLastRecord Reads only the last record from a recorded game, working back from the end of the
+// file a block at a time until it finds the start of the last line.
+func readLastRecord(path string) (Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Record{}, err
+	}
+	defer file.Close()
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Record{}, err
+	}
+
+	const block = 64 * 1024
+	tail := make([]byte, 0)
+	for offset := end; offset > 0; {
+		size := int64(block)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		chunk := make([]byte, size)
+		_, err = file.ReadAt(chunk, offset)
+		if err != nil {
+			return Record{}, err
+		}
+		tail = append(chunk, tail...)
+		line := bytes.TrimSpace(tail)
+		if i := bytes.LastIndexByte(line, '\n'); i >= 0 || offset == 0 {
+			var record Record
+			err = json.Unmarshal(line[i+1:], &record)
+			if err != nil {
+				return Record{}, fmt.Errorf("failed to decode the last record: %w", err)
+			}
+			return record, nil
+		}
+		if len(tail) > 16*1024*1024 {
+			break
+		}
+	}
+	return Record{}, fmt.Errorf("no records in '%s'", path)
+}
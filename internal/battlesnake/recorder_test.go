@@ -0,0 +1,109 @@
+package battlesnake
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"os"
+	"sync"
+	"testing"
+)
+
+func Test_Recorder_Record(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	state := GameState{Game: Game{ID: "game-1"}, Turn: 3}
+	response := MoveResponse{Move: LEFT}
+	require.NoError(t, recorder.Record(Record{Type: RecordStart, State: state}))
+	require.NoError(t, recorder.Record(Record{
+		Type:     RecordMove,
+		State:    state,
+		Response: &response,
+		Scores:   map[string]map[Move]int{"move-to-food": {LEFT: 12}},
+	}))
+
+	records, err := ReadRecords(recorder.Path("game-1"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, RecordStart, records[0].Type)
+	require.Nil(t, records[0].Response)
+	require.Equal(t, RecordMove, records[1].Type)
+	require.Equal(t, 3, records[1].State.Turn)
+	require.Equal(t, LEFT, records[1].Response.Move)
+	require.Equal(t, 12, records[1].Scores["move-to-food"][LEFT])
+}
+
+func Test_Recorder_Record_Concurrent(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	// A game being written does not hold up the others
+	unlock := recorder.lock(recorder.Path("game-1"))
+	require.NoError(t, recorder.Record(Record{Type: RecordStart, State: GameState{Game: Game{ID: "game-2"}}}))
+	unlock()
+
+	// Records for the same game are never interleaved
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		gameID := fmt.Sprintf("game-%d", i%2+1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for turn := 0; turn < 25; turn++ {
+				state := GameState{Game: Game{ID: gameID}, Turn: turn}
+				require.NoError(t, recorder.Record(Record{Type: RecordMove, State: state}))
+			}
+		}()
+	}
+	wg.Wait()
+	for game, expected := range map[string]int{"game-1": 100, "game-2": 101} {
+		records, err := recorder.Read(game)
+		require.NoError(t, err)
+		require.Len(t, records, expected)
+	}
+}
+
+func Test_Recorder_Games_Updated(t *testing.T) {
+	recorder := recordGames(t)
+	_, err := recorder.Games()
+	require.NoError(t, err)
+
+	// The summary follows the game as it is recorded
+	playing := GameState{Game: Game{ID: "playing"}, Turn: 8}
+	require.NoError(t, recorder.Record(Record{Type: RecordEnd, State: playing}))
+	games, err := recorder.Games()
+	require.NoError(t, err)
+	require.Equal(t, "playing", games[0].ID)
+	require.Equal(t, 8, games[0].Turns)
+	require.False(t, games[0].Active)
+	require.Equal(t, "draw", games[0].Result)
+}
+
+func Test_Recorder_Games_LastRecordOnly(t *testing.T) {
+	// Only the last line of a game recorded by another process is read
+	dir := t.TempDir()
+	lines := "not-json\n{\"type\":\"end\",\"state\":{\"game\":{\"id\":\"game-1\"},\"turn\":7}}\n"
+	require.NoError(t, os.WriteFile(dir+"/game-1.jsonl", []byte(lines), 0644))
+	require.NoError(t, os.WriteFile(dir+"/empty.jsonl", nil, 0644))
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+	games, err := recorder.Games()
+	require.NoError(t, err)
+	require.Len(t, games, 1)
+	require.Equal(t, "game-1", games[0].ID)
+	require.Equal(t, 7, games[0].Turns)
+}
+
+func Test_Recorder_Path(t *testing.T) {
+	recorder := &Recorder{dir: "games"}
+	require.Equal(t, "games/abc.jsonl", recorder.Path("abc"))
+	require.Equal(t, "games/___etc.jsonl", recorder.Path("/../etc"))
+	require.Equal(t, "games/unknown.jsonl", recorder.Path(""))
+}
+
+func Test_ReadRecords_Invalid(t *testing.T) {
+	path := t.TempDir() + "/invalid.jsonl"
+	require.NoError(t, os.WriteFile(path, []byte("{\"type\":\"start\"}\nnot-json\n"), 0644))
+	_, err := ReadRecords(path)
+	require.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,106 @@
+package battlesnake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symbols used to draw a board
+const (
+	SymbolEmpty  = '.'
+	SymbolFood   = '*'
+	SymbolHazard = '#'
+	SymbolHead   = 'H'
+)
+
+// opponentSymbols The letters that identify each opponent. Opponents draw their head in upper case
+// and their body in lower case. The letter 'h' is skipped so an opponent's head is not confused with yours.
+const opponentSymbols = "abcdefgijklmnopqrstuvwxyz"
+
+// bodySymbol Returns the symbol for a segment of your body; segments are numbered from the head.
+func bodySymbol(segment int) rune {
+	return rune('0' + segment%10)
+}
+
+// Render Returns a picture of the board with coordinates on the axes. Your head is drawn as 'H'
+// and your body is numbered from the head. Each opponent is drawn with its own letter, food as '*'
+// and hazards as '#'.
+func Render(state GameState) string {
+	cells := draw(state)
+	labelWidth := len(fmt.Sprint(state.Board.Height - 1))
+	var sb strings.Builder
+	for y := state.Board.Height - 1; y >= 0; y-- {
+		sb.WriteString(fmt.Sprintf("%*d", labelWidth, y))
+		for x := 0; x < state.Board.Width; x++ {
+			sb.WriteRune(' ')
+			sb.WriteRune(cells[y][x])
+		}
+		sb.WriteRune('\n')
+	}
+	sb.WriteString(strings.Repeat(" ", labelWidth))
+	for x := 0; x < state.Board.Width; x++ {
+		sb.WriteString(fmt.Sprintf(" %d", x%10))
+	}
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+// RenderWithScores Returns a picture of the board along with the score of each move. Moves
+// missing from the scores are considered unsafe.
+func RenderWithScores(state GameState, scores map[Move]int) string {
+	var sb strings.Builder
+	sb.WriteString(Render(state))
+	for i, move := range []Move{UP, DOWN, LEFT, RIGHT} {
+		if i > 0 {
+			sb.WriteString("  ")
+		}
+		if score, ok := scores[move]; ok {
+			sb.WriteString(fmt.Sprintf("%s %d", move, score))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s 🚫", move))
+		}
+	}
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+// draw Returns the symbol for each square on the board indexed by row, then column.
+func draw(state GameState) [][]rune {
+	cells := make([][]rune, state.Board.Height)
+	for y := range cells {
+		cells[y] = make([]rune, state.Board.Width)
+		for x := range cells[y] {
+			cells[y][x] = SymbolEmpty
+		}
+	}
+	set := func(coord Coord, symbol rune) {
+		if coord.Y >= 0 && coord.Y < len(cells) && coord.X >= 0 && coord.X < len(cells[coord.Y]) {
+			cells[coord.Y][coord.X] = symbol
+		}
+	}
+	for _, hazard := range state.Board.Hazards {
+		set(hazard, SymbolHazard)
+	}
+	for _, food := range state.Board.Food {
+		set(food, SymbolFood)
+	}
+
+	// Draw the snakes from tail to head so that a stacked body shows its front-most segment
+	opponent := 0
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			continue
+		}
+		letter := rune(opponentSymbols[opponent%len(opponentSymbols)])
+		opponent += 1
+		for i := len(snake.Body) - 1; i > 0; i-- {
+			set(snake.Body[i], letter)
+		}
+		set(snake.Head, letter-'a'+'A')
+	}
+	for i := len(state.You.Body) - 1; i > 0; i-- {
+		set(state.You.Body[i], bodySymbol(i))
+	}
+	set(state.You.Head, SymbolHead)
+	return cells
+}
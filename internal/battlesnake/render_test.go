@@ -0,0 +1,54 @@
+package battlesnake
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_Render(t *testing.T) {
+	state := GameState{
+		Board: Board{
+			Height:  4,
+			Width:   5,
+			Food:    []Coord{{0, 3}},
+			Hazards: []Coord{{4, 0}, {4, 1}},
+			Snakes: []Snake{
+				{
+					ID:   "you",
+					Head: Coord{1, 1},
+					Body: []Coord{{1, 1}, {1, 0}, {0, 0}},
+				},
+				{
+					ID:   "opponent",
+					Head: Coord{3, 2},
+					Body: []Coord{{3, 2}, {3, 1}, {3, 1}},
+				},
+			},
+		},
+		You: Snake{
+			ID:   "you",
+			Head: Coord{1, 1},
+			Body: []Coord{{1, 1}, {1, 0}, {0, 0}},
+		},
+	}
+	expected := "" +
+		"3 * . . . .\n" +
+		"2 . . . A .\n" +
+		"1 . H . a #\n" +
+		"0 2 1 . . #\n" +
+		"  0 1 2 3 4\n"
+	require.Equal(t, expected, Render(state))
+}
+
+func Test_RenderWithScores(t *testing.T) {
+	state := GameState{
+		Board: Board{Height: 2, Width: 2},
+		You:   Snake{Head: Coord{0, 0}},
+	}
+	expected := "" +
+		"1 . .\n" +
+		"0 H .\n" +
+		"  0 1\n" +
+		"⭡ 3  ⭣ 🚫  ⭠ 🚫  ⭢ 7\n"
+	require.Equal(t, expected, RenderWithScores(state, map[Move]int{UP: 3, RIGHT: 7}))
+}
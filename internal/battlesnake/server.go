@@ -1,9 +1,20 @@
 package battlesnake
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
 )
 
 type snake interface {
@@ -14,84 +25,417 @@ type snake interface {
 	Move(state GameState) MoveResponse
 }
 
+// evaluator is implemented by snakes that can explain how they chose a move.
+type evaluator interface {
+	Evaluate(state GameState) Evaluation
+}
+
+// previewer is implemented by snakes that can explain how they would move without it affecting the
+// games they are playing.
+type previewer interface {
+	Preview(state GameState) Evaluation
+}
+
 // SnakeServer Serves a snake for battle.
 type SnakeServer struct {
-	snake snake
+	snake    snake
+	recorder *Recorder    // optional; records every request and response
+	events   *Broadcaster // streams every request and response
+	logging  Logging
 }
 
-func NewSnakeServer(snake snake) *SnakeServer {
+func NewSnakeServer(snake snake, recorder *Recorder, logging Logging) *SnakeServer {
 	return &SnakeServer{
-		snake: snake,
+		snake:    snake,
+		recorder: recorder,
+		events:   NewBroadcaster(logging.Logger),
+		logging:  logging,
 	}
 }
 
 func (s *SnakeServer) HandleIndex(w http.ResponseWriter, r *http.Request) {
+	metrics.Requests.Inc("/")
+	// Every path that no other endpoint serves ends up here
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found", r.URL.Path))
+		return
+	}
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
 	response := s.snake.Info()
 	w.Header().Set("Content-Type", "application/json")
 	err := json.NewEncoder(w).Encode(response)
 	if err != nil {
-		log.Printf("ERROR: Failed to encode info response, %s", err)
+		s.logging.Logger.Error().Err(err).Str("endpoint", "/").Msg("Failed to encode info response")
+		metrics.Errors.Inc("/", metrics.KindEncode)
 	}
 }
 
-func (s *SnakeServer) HandleStart(w http.ResponseWriter, r *http.Request) {
-	state := GameState{}
-	err := json.NewDecoder(r.Body).Decode(&state)
+func (s *SnakeServer) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	err := metrics.HandleMetrics(w, r)
 	if err != nil {
-		log.Printf("ERROR: Failed to decode start json, %s", err)
+		s.logging.Logger.Error().Err(err).Str("endpoint", "/metrics").Msg("Failed to write metrics")
+		metrics.Errors.Inc("/metrics", metrics.KindEncode)
+	}
+}
+
+func (s *SnakeServer) HandleStart(w http.ResponseWriter, r *http.Request) {
+	metrics.Requests.Inc("/start")
+	state, ok := s.readState(w, r, "/start", GameState.Validate)
+	if !ok {
 		return
 	}
-	s.snake.Start(state)
+	logger := forRequest(s.logging.Logger, "/start", s.snake.Name(), state)
+	started := time.Now()
+	s.protect(logger, "/start", func() { s.snake.Start(state) })
+	metrics.GamesStarted.Inc(s.snake.Name())
+	s.record(logger, Record{Type: RecordStart, State: state}, started)
+	logger.Info().Int64("latency-ms", time.Since(started).Milliseconds()).Msg("Started game")
 }
 
 func (s *SnakeServer) HandleMove(w http.ResponseWriter, r *http.Request) {
-	state := GameState{}
-	err := json.NewDecoder(r.Body).Decode(&state)
-	if err != nil {
-		log.Printf("ERROR: Failed to decode move json, %s", err)
+	metrics.Requests.Inc("/move")
+	state, ok := s.readState(w, r, "/move", GameState.Validate)
+	if !ok {
 		return
 	}
-	response := s.snake.Move(state)
+	logger := forRequest(s.logging.Logger, "/move", s.snake.Name(), state)
+	started := time.Now()
+	evaluation := s.evaluate(logger, "/move", state)
+	latency := time.Since(started)
+	metrics.MoveLatency.Observe(latency.Seconds(), s.snake.Name())
+	response := evaluation.Response
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(response)
+	err := json.NewEncoder(w).Encode(response)
 	if err != nil {
-		log.Printf("ERROR: Failed to encode move response, %s", err)
+		logger.Error().Err(err).Msg("Failed to encode move response")
+		metrics.Errors.Inc("/move", metrics.KindEncode)
 		return
 	}
+	s.record(logger, Record{
+		Type:        RecordMove,
+		State:       state,
+		Response:    &response,
+		Scores:      evaluation.Scores,
+		Explanation: evaluation.Explanation,
+	}, started)
+	if s.logging.sampled(state.Turn) {
+		logger.Info().
+			Str("move", string(response.Move)).
+			Int("health", state.You.Health).
+			Int("length", state.You.Length).
+			Int64("latency-ms", latency.Milliseconds()).
+			Msg("Moved")
+	}
+}
+
+// evaluate Asks the snake for its move, along with its scores if the snake can explain itself. If the
+// snake panics, the fallback move is played instead.
+func (s *SnakeServer) evaluate(logger zerolog.Logger, endpoint string, state GameState) (evaluation Evaluation) {
+	defer func() {
+		if r := recover(); r != nil {
+			recovered(logger, endpoint, r)
+			evaluation = Evaluation{Response: MoveResponse{Move: FallbackMove(state)}, Panic: fmt.Sprint(r)}
+		}
+	}()
+	if e, ok := s.snake.(evaluator); ok {
+		return e.Evaluate(state)
+	}
+	return Evaluation{Response: s.snake.Move(state)}
+}
+
+// preview Asks the snake how it would move, without it affecting the games the snake is playing. A
+// snake that cannot preview its moves is asked to evaluate the state instead.
+func (s *SnakeServer) preview(logger zerolog.Logger, state GameState) (evaluation Evaluation) {
+	p, ok := s.snake.(previewer)
+	if !ok {
+		return s.evaluate(logger, "/debug/evaluate", state)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			recovered(logger, "/debug/evaluate", r)
+			evaluation = Evaluation{Response: MoveResponse{Move: FallbackMove(state)}, Panic: fmt.Sprint(r)}
+		}
+	}()
+	return p.Preview(state)
+}
+
+// protect Calls the snake, recovering if it panics.
+func (s *SnakeServer) protect(logger zerolog.Logger, endpoint string, call func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			recovered(logger, endpoint, r)
+		}
+	}()
+	call()
+}
+
+// recovered Logs and counts a panic recovered from the snake.
+func recovered(logger zerolog.Logger, endpoint string, r interface{}) {
+	logger.Error().
+		Str("panic", fmt.Sprint(r)).
+		Str("stack", string(debug.Stack())).
+		Msg("Recovered from panic")
+	metrics.Panics.Inc(endpoint)
 }
 
 func (s *SnakeServer) HandleEnd(w http.ResponseWriter, r *http.Request) {
+	metrics.Requests.Inc("/end")
+	// A snake that has been eliminated is no longer on the board, so only the board is validated
+	state, ok := s.readState(w, r, "/end", GameState.ValidateBoard)
+	if !ok {
+		return
+	}
+	logger := forRequest(s.logging.Logger, "/end", s.snake.Name(), state)
+	started := time.Now()
+	s.protect(logger, "/end", func() { s.snake.End(state) })
+	metrics.GamesEnded.Inc(s.snake.Name())
+	s.record(logger, Record{Type: RecordEnd, State: state}, started)
+	logger.Info().Int64("latency-ms", time.Since(started).Milliseconds()).Msg("Ended game")
+}
+
+// HandleEvaluate Returns how the snake would move given any game state. Nothing is recorded and the
+// games the snake is playing are not affected.
+func (s *SnakeServer) HandleEvaluate(w http.ResponseWriter, r *http.Request) {
+	metrics.Requests.Inc("/debug/evaluate")
+	state, ok := s.readState(w, r, "/debug/evaluate", GameState.Validate)
+	if !ok {
+		return
+	}
+	logger := forRequest(s.logging.Logger, "/debug/evaluate", s.snake.Name(), state)
+	evaluation := s.preview(logger, state)
+	scores := make(map[Move]int)
+	if evaluation.Explanation != nil {
+		for _, ranked := range evaluation.Explanation.Ranking {
+			scores[ranked.Move] = ranked.Score
+		}
+	}
+	response := EvaluateResponse{
+		Move:        evaluation.Response.Move,
+		Scores:      evaluation.Scores,
+		Explanation: evaluation.Explanation,
+		Board:       RenderWithScores(state, scores),
+		Panic:       evaluation.Panic,
+	}
+	if len(evaluation.Panic) > 0 {
+		// No move was scored, but that does not make every move fatal
+		response.Board = Render(state)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to encode evaluate response")
+		metrics.Errors.Inc("/debug/evaluate", metrics.KindEncode)
+	}
+}
+
+// MaxBodyBytes The largest request body accepted. A game state on the largest boards is well under this.
+const MaxBodyBytes = 1 << 20
+
+// readState Reads and validates the game state posted to an endpoint. If the state cannot be read, an
+// error response is written and false is returned.
+func (s *SnakeServer) readState(w http.ResponseWriter, r *http.Request, endpoint string, validate func(GameState) error) (GameState, bool) {
 	state := GameState{}
-	err := json.NewDecoder(r.Body).Decode(&state)
+	if !allowMethods(w, r, http.MethodPost) {
+		return state, false
+	}
+	logger := s.logging.Logger.With().Str("endpoint", endpoint).Logger()
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodyBytes+1))
 	if err != nil {
-		log.Printf("ERROR: Failed to decode end json, %s", err)
+		logger.Error().Err(err).Msg("Failed to read request")
+		metrics.Errors.Inc(endpoint, metrics.KindDecode)
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return state, false
+	}
+	if len(body) > MaxBodyBytes {
+		logger.Error().Int("max-bytes", MaxBodyBytes).Msg("Request is too large")
+		metrics.Errors.Inc(endpoint, metrics.KindDecode)
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", MaxBodyBytes))
+		return state, false
+	}
+	err = json.Unmarshal(body, &state)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to decode json")
+		metrics.Errors.Inc(endpoint, metrics.KindDecode)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid json: %s", err))
+		return state, false
+	}
+	if validate != nil {
+		err = validate(state)
+		if err != nil {
+			logger := forRequest(s.logging.Logger, endpoint, s.snake.Name(), state)
+			logger.Error().Err(err).Msg("Invalid game state")
+			metrics.Errors.Inc(endpoint, metrics.KindInvalid)
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid game state: %s", err))
+			return state, false
+		}
+	}
+	return state, true
+}
+
+// allowMethods Answers with 405 Method Not Allowed unless the request uses one of the given methods.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	return false
+}
+
+// writeError Answers with the status code and a JSON error body.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	// Nothing more can be done if the error itself cannot be written
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+}
+
+// HandleEvents Streams every request and response as server-sent events while games are in progress.
+func (s *SnakeServer) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	s.events.HandleEvents(w, r)
+}
+
+func (s *SnakeServer) record(logger zerolog.Logger, record Record, started time.Time) {
+	record.Time = started
+	record.LatencyMS = time.Since(started).Milliseconds()
+	s.events.Publish(record)
+	if s.recorder == nil {
 		return
 	}
-	s.snake.End(state)
+	err := s.recorder.Record(record)
+	if err != nil {
+		logger.Error().Err(err).Str("record", record.Type).Msg("Failed to record")
+	}
 }
 
 // Middleware
 
 const ServerID = "battlesnake/github/starter-snake-go"
 
-func RunServer(snake snake, port string) {
-	server := NewSnakeServer(snake)
-	http.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
-		writer.Header().Set("Server", ServerID)
-		server.HandleIndex(writer, request)
-	})
-	http.HandleFunc("/start", func(writer http.ResponseWriter, request *http.Request) {
-		writer.Header().Set("Server", ServerID)
-		server.HandleStart(writer, request)
-	})
-	http.HandleFunc("/move", func(writer http.ResponseWriter, request *http.Request) {
-		writer.Header().Set("Server", ServerID)
-		server.HandleMove(writer, request)
-	})
-	http.HandleFunc("/end", func(writer http.ResponseWriter, request *http.Request) {
+// ServerConfig Configures how the snake is served.
+type ServerConfig struct {
+	Addr            string        // the address to listen on, like ':8000'
+	ReadTimeout     time.Duration // the longest time to read a request
+	WriteTimeout    time.Duration // the longest time to write any response but an event stream; zero for no limit
+	IdleTimeout     time.Duration // the longest time to keep an idle connection open
+	ShutdownTimeout time.Duration // the longest time to wait for requests in flight when shutting down
+	TLSCertFile     string        // optional; serves TLS when set along with the key file
+	TLSKeyFile      string        // optional; serves TLS when set along with the cert file
+}
+
+// DefaultServerConfig Returns the configuration used to serve a snake on a port.
+func DefaultServerConfig(port string) ServerConfig {
+	return ServerConfig{
+		Addr:            ":" + port,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+	}
+}
+
+// timeoutBody The error body sent when a response takes longer than the write timeout.
+const timeoutBody = `{"error":"the response took too long to write"}`
+
+// Handler Routes each endpoint to the snake, the viewer and the metrics.
+func (s *SnakeServer) Handler() http.Handler {
+	return s.handler(0)
+}
+
+// handler Routes each endpoint, failing any response that takes longer than the write timeout to
+// write. A zero timeout leaves every response unbounded.
+func (s *SnakeServer) handler(writeTimeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	route := func(pattern string, handle http.HandlerFunc) {
+		var handler http.Handler = handle
+		if writeTimeout > 0 {
+			handler = http.TimeoutHandler(handle, writeTimeout, timeoutBody)
+		}
+		mux.HandleFunc(pattern, func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set("Server", ServerID)
+			handler.ServeHTTP(writer, request)
+		})
+	}
+	route("/", s.HandleIndex)
+	route("/start", s.HandleStart)
+	route("/move", s.HandleMove)
+	route("/end", s.HandleEnd)
+	route("/debug/evaluate", s.HandleEvaluate)
+	route("/metrics", s.HandleMetrics)
+	viewer := NewViewer(s.recorder, s.logging.Logger)
+	route("/viewer/", viewer.HandlePage)
+	route("/viewer/games", viewer.HandleGames)
+	route("/viewer/games/", viewer.HandleGames)
+
+	// Event streams stay open as long as games are played, so are never timed out
+	mux.HandleFunc("/events", func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Server", ServerID)
-		server.HandleEnd(writer, request)
+		s.HandleEvents(writer, request)
 	})
-	log.Printf("Running '%s' at http://0.0.0.0:%s...\n", snake.Name(), port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	return mux
+}
+
+// RunServer Serves the snake until the process is interrupted or terminated.
+func RunServer(snake snake, config ServerConfig, recorder *Recorder, logging Logging) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logging.Logger.Info().Str("snake", snake.Name()).Str("addr", config.Addr).Msg("Running")
+	return NewSnakeServer(snake, recorder, logging).ListenAndServe(ctx, config)
+}
+
+// ListenAndServe Serves the snake until the context is done, then shuts down gracefully by letting
+// the requests in flight finish.
+func (s *SnakeServer) ListenAndServe(ctx context.Context, config ServerConfig) error {
+	if (len(config.TLSCertFile) > 0) != (len(config.TLSKeyFile) > 0) {
+		return fmt.Errorf("both a TLS cert file and key file are needed to serve TLS")
+	}
+	listener, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, listener, config)
+}
+
+func (s *SnakeServer) serve(ctx context.Context, listener net.Listener, config ServerConfig) error {
+	// The write timeout is applied by route rather than to every connection so that event streams
+	// can stay open
+	server := &http.Server{
+		Handler:     s.handler(config.WriteTimeout),
+		ReadTimeout: config.ReadTimeout,
+		IdleTimeout: config.IdleTimeout,
+	}
+	// Event streams never finish on their own
+	server.RegisterOnShutdown(s.events.Close)
+
+	served := make(chan error, 1)
+	go func() {
+		if len(config.TLSCertFile) > 0 {
+			served <- server.ServeTLS(listener, config.TLSCertFile, config.TLSKeyFile)
+		} else {
+			served <- server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	s.logging.Logger.Info().Dur("timeout", config.ShutdownTimeout).Msg("Shutting down, waiting for requests in flight")
+	shutdownCtx := context.Background()
+	if config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, config.ShutdownTimeout)
+		defer cancel()
+	}
+	err := server.Shutdown(shutdownCtx)
+	if served := <-served; served != http.ErrServerClosed {
+		return served
+	}
+	return err
 }
@@ -0,0 +1,426 @@
+package battlesnake
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// quiet Logs nothing.
+var quiet = Logging{Logger: zerolog.Nop()}
+
+// testSnake A snake that always moves up and explains why.
+type testSnake struct {
+	moves int
+}
+
+func (s *testSnake) Name() string       { return "test" }
+func (s *testSnake) Info() InfoResponse { return InfoResponse{APIVersion: "1"} }
+func (s *testSnake) Start(_ GameState)  {}
+func (s *testSnake) End(_ GameState)    {}
+func (s *testSnake) Move(_ GameState) MoveResponse {
+	s.moves += 1
+	return MoveResponse{Move: UP}
+}
+
+func (s *testSnake) Evaluate(state GameState) Evaluation {
+	return Evaluation{
+		Response: s.Move(state),
+		Scores:   map[string]map[Move]int{"always-up": {UP: 10}},
+		Explanation: &Explanation{
+			Strategies: []StrategyExplanation{{Name: "always-up", Contributions: map[Move]int{UP: 10}}},
+			Ranking:    []RankedMove{{Move: UP, Score: 10}, {Move: LEFT, Score: 0}},
+			Chosen:     UP,
+		},
+	}
+}
+
+const testState = `{
+	"game": {"id": "game-1"},
+	"turn": 3,
+	"board": {"width": 3, "height": 2, "snakes": [{"id": "you", "head": {"x": 0, "y": 0}, "body": [{"x": 0, "y": 0}]}]},
+	"you": {"id": "you", "head": {"x": 0, "y": 0}, "body": [{"x": 0, "y": 0}]}
+}`
+
+func Test_SnakeServer_HandleEvaluate(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	require.NoError(t, err)
+	server := NewSnakeServer(&testSnake{}, recorder, quiet)
+
+	w := httptest.NewRecorder()
+	server.HandleEvaluate(w, httptest.NewRequest(http.MethodPost, "/debug/evaluate", strings.NewReader(testState)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response EvaluateResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Equal(t, UP, response.Move)
+	require.Equal(t, 10, response.Scores["always-up"][UP])
+	require.Equal(t, UP, response.Explanation.Chosen)
+	require.Equal(t, ""+
+		"1 . . .\n"+
+		"0 H . .\n"+
+		"  0 1 2\n"+
+		"⭡ 10  ⭣ 🚫  ⭠ 0  ⭢ 🚫\n", response.Board)
+
+	// Evaluating has no side effects
+	_, err = ReadRecords(recorder.Path("game-1"))
+	require.Error(t, err)
+}
+
+// previewingSnake A snake that moves left in games but would move right when previewing a state.
+type previewingSnake struct {
+	testSnake
+}
+
+func (s *previewingSnake) Evaluate(_ GameState) Evaluation {
+	s.moves += 1
+	return Evaluation{Response: MoveResponse{Move: LEFT}}
+}
+
+func (s *previewingSnake) Preview(_ GameState) Evaluation {
+	return Evaluation{Response: MoveResponse{Move: RIGHT}}
+}
+
+func Test_SnakeServer_HandleEvaluate_Preview(t *testing.T) {
+	snake := &previewingSnake{}
+	server := NewSnakeServer(snake, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleEvaluate(w, httptest.NewRequest(http.MethodPost, "/debug/evaluate", strings.NewReader(testState)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response EvaluateResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Equal(t, RIGHT, response.Move)
+	require.Zero(t, snake.moves, "the games being played are not affected")
+}
+
+func Test_SnakeServer_HandleEvaluate_MethodNotAllowed(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleEvaluate(w, httptest.NewRequest(http.MethodGet, "/debug/evaluate", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func Test_SnakeServer_HandleEvaluate_Invalid(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleEvaluate(w, httptest.NewRequest(http.MethodPost, "/debug/evaluate", strings.NewReader("{")))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_SnakeServer_Metrics(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	requests := metrics.Requests.Value("/move")
+	moves := metrics.MoveLatency.Count("test")
+	decodeErrors := metrics.Errors.Value("/move", metrics.KindDecode)
+	started := metrics.GamesStarted.Value("test")
+	ended := metrics.GamesEnded.Value("test")
+
+	server.HandleStart(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start", strings.NewReader(testState)))
+	server.HandleMove(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(testState)))
+	server.HandleMove(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/move", strings.NewReader("{")))
+	server.HandleEnd(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/end", strings.NewReader(testState)))
+
+	require.Equal(t, requests+2, metrics.Requests.Value("/move"))
+	require.Equal(t, moves+1, metrics.MoveLatency.Count("test"))
+	require.Equal(t, decodeErrors+1, metrics.Errors.Value("/move", metrics.KindDecode))
+	require.Equal(t, started+1, metrics.GamesStarted.Value("test"))
+	require.Equal(t, ended+1, metrics.GamesEnded.Value("test"))
+}
+
+func Test_SnakeServer_Errors(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	hugeBoard := strings.Replace(testState, `"width": 3, "height": 2`, `"width": 100000, "height": 100000`, 1)
+	offBoard := strings.Replace(testState, `"you": {"id": "you", "head": {"x": 0, "y": 0}`, `"you": {"id": "you", "head": {"x": 9, "y": 0}`, 1)
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		method  string
+		path    string // optional; defaults to '/'
+		body    string
+		status  int
+		err     string
+	}{
+		"index by post": {
+			handler: server.HandleIndex,
+			method:  http.MethodPost,
+			status:  http.StatusMethodNotAllowed,
+			err:     "method POST not allowed",
+		},
+		"unknown path": {
+			handler: server.HandleIndex,
+			method:  http.MethodGet,
+			path:    "/favicon.ico",
+			status:  http.StatusNotFound,
+			err:     "/favicon.ico not found",
+		},
+		"move by get": {
+			handler: server.HandleMove,
+			method:  http.MethodGet,
+			status:  http.StatusMethodNotAllowed,
+			err:     "method GET not allowed",
+		},
+		"invalid json": {
+			handler: server.HandleMove,
+			method:  http.MethodPost,
+			body:    "{",
+			status:  http.StatusBadRequest,
+			err:     "invalid json: unexpected end of JSON input",
+		},
+		"too large": {
+			handler: server.HandleStart,
+			method:  http.MethodPost,
+			body:    strings.Repeat(" ", MaxBodyBytes+1),
+			status:  http.StatusRequestEntityTooLarge,
+			err:     "request body is larger than 1048576 bytes",
+		},
+		"head off board": {
+			handler: server.HandleMove,
+			method:  http.MethodPost,
+			body:    offBoard,
+			status:  http.StatusUnprocessableEntity,
+			err:     "invalid game state: you.head (9,0) is off the 3x2 board",
+		},
+		"huge board": {
+			handler: server.HandleEvaluate,
+			method:  http.MethodPost,
+			body:    hugeBoard,
+			status:  http.StatusUnprocessableEntity,
+			err:     "invalid game state: board is 100000x100000; expected at most 25x25",
+		},
+		"huge board at end": {
+			handler: server.HandleEnd,
+			method:  http.MethodPost,
+			body:    hugeBoard,
+			status:  http.StatusUnprocessableEntity,
+			err:     "invalid game state: board is 100000x100000; expected at most 25x25",
+		},
+		"not on board": {
+			handler: server.HandleStart,
+			method:  http.MethodPost,
+			body:    strings.Replace(testState, `"snakes": [{"id": "you"`, `"snakes": [{"id": "them"`, 1),
+			status:  http.StatusUnprocessableEntity,
+			err:     "invalid game state: you (id 'you') is not one of the 1 board.snakes",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := test.path
+			if len(path) == 0 {
+				path = "/"
+			}
+			w := httptest.NewRecorder()
+			test.handler(w, httptest.NewRequest(test.method, path, strings.NewReader(test.body)))
+			require.Equal(t, test.status, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var response ErrorResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			require.Equal(t, test.err, response.Error)
+		})
+	}
+}
+
+func Test_SnakeServer_HandleMove_Allow(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleMove(w, httptest.NewRequest(http.MethodGet, "/move", nil))
+	require.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+}
+
+func Test_SnakeServer_HandleEnd_Eliminated(t *testing.T) {
+	server := NewSnakeServer(&testSnake{}, nil, quiet)
+
+	// A snake that lost is no longer on the board when the game ends
+	eliminated := strings.Replace(testState, `"snakes": [{"id": "you"`, `"snakes": [{"id": "them"`, 1)
+	w := httptest.NewRecorder()
+	server.HandleEnd(w, httptest.NewRequest(http.MethodPost, "/end", strings.NewReader(eliminated)))
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_SnakeServer_HandleMove(t *testing.T) {
+	snake := &testSnake{}
+	server := NewSnakeServer(snake, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleMove(w, httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(testState)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, snake.moves)
+
+	var response MoveResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Equal(t, UP, response.Move)
+}
+
+// panickingSnake A snake with a bug.
+type panickingSnake struct {
+	testSnake
+}
+
+func (s *panickingSnake) Start(_ GameState) { panic("start") }
+func (s *panickingSnake) End(_ GameState)   { panic("end") }
+func (s *panickingSnake) Move(_ GameState) MoveResponse {
+	Coord{}.Move("sideways")
+	return MoveResponse{Move: UP}
+}
+func (s *panickingSnake) Evaluate(state GameState) Evaluation {
+	return Evaluation{Response: s.Move(state)}
+}
+
+func Test_SnakeServer_Recovers(t *testing.T) {
+	server := NewSnakeServer(&panickingSnake{}, nil, quiet)
+	panics := metrics.Panics.Value("/move")
+
+	w := httptest.NewRecorder()
+	server.HandleMove(w, httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(testState)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, panics+1, metrics.Panics.Value("/move"))
+
+	// The fallback avoids the wall below and to the left
+	var response MoveResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Equal(t, UP, response.Move)
+
+	handlers := map[string]http.HandlerFunc{"/start": server.HandleStart, "/end": server.HandleEnd}
+	for endpoint, handler := range handlers {
+		before := metrics.Panics.Value(endpoint)
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, endpoint, strings.NewReader(testState)))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, before+1, metrics.Panics.Value(endpoint))
+	}
+}
+
+func Test_SnakeServer_HandleEvaluate_Recovers(t *testing.T) {
+	server := NewSnakeServer(&panickingSnake{}, nil, quiet)
+	w := httptest.NewRecorder()
+	server.HandleEvaluate(w, httptest.NewRequest(http.MethodPost, "/debug/evaluate", strings.NewReader(testState)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response EvaluateResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Equal(t, UP, response.Move)
+	require.Equal(t, "unexpected move", response.Panic)
+	require.NotContains(t, response.Board, "🚫")
+}
+
+// slowSnake A snake that waits to be released before it moves.
+type slowSnake struct {
+	testSnake
+	moving  chan bool
+	release chan bool
+}
+
+func (s *slowSnake) Move(state GameState) MoveResponse {
+	s.moving <- true
+	<-s.release
+	return s.testSnake.Move(state)
+}
+
+func (s *slowSnake) Evaluate(state GameState) Evaluation {
+	return Evaluation{Response: s.Move(state)}
+}
+
+func Test_SnakeServer_ListenAndServe_GracefulShutdown(t *testing.T) {
+	snake := &slowSnake{moving: make(chan bool), release: make(chan bool)}
+	server := NewSnakeServer(snake, nil, quiet)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() {
+		served <- server.serve(ctx, listener, DefaultServerConfig("0"))
+	}()
+
+	// An event stream is open throughout
+	events, err := http.Get(url + "/events")
+	require.NoError(t, err)
+	defer events.Body.Close()
+
+	type result struct {
+		response *http.Response
+		err      error
+	}
+	moved := make(chan result)
+	go func() {
+		response, err := http.Post(url+"/move", "application/json", strings.NewReader(testState))
+		moved <- result{response, err}
+	}()
+
+	// Shut down while the move is in flight
+	<-snake.moving
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	snake.release <- true
+
+	move := <-moved
+	require.NoError(t, move.err)
+	defer move.response.Body.Close()
+	require.Equal(t, http.StatusOK, move.response.StatusCode)
+	var response MoveResponse
+	require.NoError(t, json.NewDecoder(move.response.Body).Decode(&response))
+	require.Equal(t, UP, response.Move)
+
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the server did not shut down")
+	}
+}
+
+func Test_SnakeServer_ListenAndServe_WriteTimeout(t *testing.T) {
+	snake := &slowSnake{moving: make(chan bool), release: make(chan bool)}
+	server := NewSnakeServer(snake, nil, quiet)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := DefaultServerConfig("0")
+	config.WriteTimeout = 100 * time.Millisecond
+	go func() {
+		_ = server.serve(ctx, listener, config)
+	}()
+
+	events, err := http.Get(url + "/events")
+	require.NoError(t, err)
+	defer events.Body.Close()
+
+	// A move that takes too long fails
+	go func() {
+		<-snake.moving
+		time.Sleep(200 * time.Millisecond)
+		snake.release <- true
+	}()
+	slow, err := http.Post(url+"/move", "application/json", strings.NewReader(testState))
+	require.NoError(t, err)
+	var failure ErrorResponse
+	require.NoError(t, json.NewDecoder(slow.Body).Decode(&failure))
+	slow.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, slow.StatusCode)
+	require.Equal(t, "the response took too long to write", failure.Error)
+
+	// The event stream outlives the timeout
+	server.events.Publish(Record{Type: RecordMove})
+	line, err := bufio.NewReader(events.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: move\n", line)
+}
+
+func Test_SnakeServer_ListenAndServe_TLSNeedsCertAndKey(t *testing.T) {
+	config := DefaultServerConfig("0")
+	config.TLSCertFile = "cert.pem"
+	err := NewSnakeServer(&testSnake{}, nil, quiet).ListenAndServe(context.Background(), config)
+	require.EqualError(t, err, "both a TLS cert file and key file are needed to serve TLS")
+}
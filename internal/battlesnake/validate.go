@@ -0,0 +1,42 @@
+package battlesnake
+
+import "fmt"
+
+// MaxBoardSize The widest and tallest board accepted. The largest boards played are 25x25; anything
+// bigger would only make the snake search and render huge, empty boards.
+const MaxBoardSize = 25
+
+// ValidateBoard Returns a descriptive error unless the board has a positive width and height of
+// no more than MaxBoardSize.
+func (state GameState) ValidateBoard() error {
+	board := state.Board
+	if board.Width <= 0 || board.Height <= 0 {
+		return fmt.Errorf("board is %dx%d; expected a positive width and height", board.Width, board.Height)
+	}
+	if board.Width > MaxBoardSize || board.Height > MaxBoardSize {
+		return fmt.Errorf("board is %dx%d; expected at most %dx%d", board.Width, board.Height, MaxBoardSize, MaxBoardSize)
+	}
+	return nil
+}
+
+// Validate Returns a descriptive error if the game state is not one a snake can move from.
+func (state GameState) Validate() error {
+	err := state.ValidateBoard()
+	if err != nil {
+		return err
+	}
+	board := state.Board
+	you := state.You
+	if len(you.ID) == 0 {
+		return fmt.Errorf("you has no id")
+	}
+	if !NewGrid(state).InBounds(you.Head) {
+		return fmt.Errorf("you.head %s is off the %dx%d board", you.Head, board.Width, board.Height)
+	}
+	for _, snake := range board.Snakes {
+		if snake.ID == you.ID {
+			return nil
+		}
+	}
+	return fmt.Errorf("you (id '%s') is not one of the %d board.snakes", you.ID, len(board.Snakes))
+}
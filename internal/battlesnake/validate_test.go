@@ -0,0 +1,50 @@
+package battlesnake
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_GameState_Validate(t *testing.T) {
+	you := Snake{ID: "you", Head: Coord{X: 1, Y: 1}, Body: []Coord{{X: 1, Y: 1}}}
+	valid := func() GameState {
+		return GameState{
+			Board: Board{Width: 3, Height: 3, Snakes: []Snake{{ID: "them"}, you}},
+			You:   you,
+		}
+	}
+	require.NoError(t, valid().Validate())
+
+	tests := map[string]struct {
+		modify func(state *GameState)
+		err    string
+	}{
+		"empty board": {
+			modify: func(state *GameState) { state.Board.Width = 0 },
+			err:    "board is 0x3; expected a positive width and height",
+		},
+		"huge board": {
+			modify: func(state *GameState) { state.Board.Width, state.Board.Height = 100000, 100000 },
+			err:    "board is 100000x100000; expected at most 25x25",
+		},
+		"no id": {
+			modify: func(state *GameState) { state.You.ID = "" },
+			err:    "you has no id",
+		},
+		"head off board": {
+			modify: func(state *GameState) { state.You.Head = Coord{X: 3, Y: 1} },
+			err:    "you.head (3,1) is off the 3x3 board",
+		},
+		"not on board": {
+			modify: func(state *GameState) { state.Board.Snakes = state.Board.Snakes[:1] },
+			err:    "you (id 'you') is not one of the 1 board.snakes",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			state := valid()
+			test.modify(&state)
+			require.EqualError(t, state.Validate(), test.err)
+		})
+	}
+}
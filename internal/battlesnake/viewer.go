@@ -0,0 +1,68 @@
+package battlesnake
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strings"
+)
+
+//go:embed viewer/index.html
+var viewerPage []byte
+
+// Viewer Serves a web page that replays recorded games turn by turn. It works entirely
+// from local recordings.
+type Viewer struct {
+	recorder *Recorder
+	logger   zerolog.Logger
+}
+
+func NewViewer(recorder *Recorder, logger zerolog.Logger) *Viewer {
+	return &Viewer{
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// HandlePage Serves the viewer web page.
+func (v *Viewer) HandlePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := w.Write(viewerPage)
+	if err != nil {
+		v.logger.Error().Err(err).Msg("Failed to write viewer page")
+	}
+}
+
+// HandleGames Serves the list of recorded games or, given a game ID, all the records of that game.
+func (v *Viewer) HandleGames(w http.ResponseWriter, r *http.Request) {
+	if v.recorder == nil {
+		writeError(w, http.StatusNotFound, "recording is disabled")
+		return
+	}
+
+	var response interface{}
+	gameID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/viewer/games"), "/")
+	if len(gameID) == 0 {
+		games, err := v.recorder.Games()
+		if err != nil {
+			v.logger.Error().Err(err).Msg("Failed to list recorded games")
+			writeError(w, http.StatusInternalServerError, "failed to list games")
+			return
+		}
+		response = games
+	} else {
+		records, err := v.recorder.Read(gameID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "game not found")
+			return
+		}
+		response = records
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		v.logger.Error().Err(err).Msg("Failed to encode games")
+	}
+}
@@ -0,0 +1,77 @@
+package battlesnake
+
+import (
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func recordGames(t *testing.T) *Recorder {
+	recorder, err := NewRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	you := Snake{ID: "you", Name: "Battle Snake"}
+	finished := GameState{Game: Game{ID: "finished"}, You: you, Board: Board{Snakes: []Snake{you}}}
+	require.NoError(t, recorder.Record(Record{Type: RecordStart, State: finished}))
+	require.NoError(t, recorder.Record(Record{Type: RecordMove, State: finished, Response: &MoveResponse{Move: UP}}))
+	finished.Turn = 1
+	require.NoError(t, recorder.Record(Record{Type: RecordEnd, State: finished}))
+
+	playing := GameState{Game: Game{ID: "playing"}, Turn: 7, You: you}
+	require.NoError(t, recorder.Record(Record{Type: RecordMove, State: playing, Response: &MoveResponse{Move: LEFT}}))
+	return recorder
+}
+
+func Test_Recorder_Games(t *testing.T) {
+	games, err := recordGames(t).Games()
+	require.NoError(t, err)
+	require.Len(t, games, 2)
+
+	byID := make(map[string]GameSummary)
+	for _, game := range games {
+		byID[game.ID] = game
+	}
+	require.False(t, byID["finished"].Active)
+	require.Equal(t, "won", byID["finished"].Result)
+	require.Equal(t, "Battle Snake", byID["finished"].Snake)
+	require.True(t, byID["playing"].Active)
+	require.Equal(t, 7, byID["playing"].Turns)
+}
+
+func Test_Viewer_HandlePage(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewViewer(nil, zerolog.Nop()).HandlePage(w, httptest.NewRequest(http.MethodGet, "/viewer/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "<title>Snacks Viewer</title>")
+}
+
+func Test_Viewer_HandleGames(t *testing.T) {
+	viewer := NewViewer(recordGames(t), zerolog.Nop())
+
+	w := httptest.NewRecorder()
+	viewer.HandleGames(w, httptest.NewRequest(http.MethodGet, "/viewer/games", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var games []GameSummary
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&games))
+	require.Len(t, games, 2)
+
+	w = httptest.NewRecorder()
+	viewer.HandleGames(w, httptest.NewRequest(http.MethodGet, "/viewer/games/finished", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []Record
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&records))
+	require.Len(t, records, 3)
+
+	w = httptest.NewRecorder()
+	viewer.HandleGames(w, httptest.NewRequest(http.MethodGet, "/viewer/games/missing", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Viewer_RecordingDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewViewer(nil, zerolog.Nop()).HandleGames(w, httptest.NewRequest(http.MethodGet, "/viewer/games", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,309 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EndpointStart = "/start"
+	EndpointMove  = "/move"
+	EndpointEnd   = "/end"
+)
+
+// Config How a load test replays games against a snake server.
+type Config struct {
+	URL         string        // the base URL of the snake server
+	Concurrency int           // the number of games played at once
+	Rate        float64       // the most requests sent per second across all games; zero for no limit
+	Repeat      int           // the number of times each game is played
+	Timeout     time.Duration // the longest to wait for each response; zero for no limit
+}
+
+// Request A request that a snake server received during a recorded game.
+type Request struct {
+	Endpoint string
+	State    b.GameState
+}
+
+// Game The requests received by one snake during a recorded game, in the order they were sent.
+type Game struct {
+	ID       string
+	SnakeID  string
+	Requests []Request
+}
+
+// Games Splits recorded games into the requests received by each snake. Imported games only
+// record moves, so a game is started with its first state and ended with its last.
+func Games(records []b.Record) []Game {
+	type key struct {
+		gameID  string
+		snakeID string
+	}
+	games := make([]Game, 0)
+	index := make(map[key]int)
+	for _, record := range records {
+		k := key{gameID: record.State.Game.ID, snakeID: record.State.You.ID}
+		i, ok := index[k]
+		if !ok {
+			i = len(games)
+			index[k] = i
+			games = append(games, Game{ID: k.gameID, SnakeID: k.snakeID})
+		}
+		games[i].Requests = append(games[i].Requests, Request{
+			Endpoint: "/" + record.Type,
+			State:    record.State,
+		})
+	}
+
+	for i, game := range games {
+		first, last := game.Requests[0], game.Requests[len(game.Requests)-1]
+		if first.Endpoint != EndpointStart {
+			game.Requests = append([]Request{{Endpoint: EndpointStart, State: first.State}}, game.Requests...)
+		}
+		if last.Endpoint != EndpointEnd {
+			game.Requests = append(game.Requests, Request{Endpoint: EndpointEnd, State: last.State})
+		}
+		games[i] = game
+	}
+	return games
+}
+
+// Report The outcome of a load test.
+type Report struct {
+	Games     int                        // the number of games played
+	Requests  int                        // the number of requests sent
+	Latencies map[string][]time.Duration // the latency of each successful request by endpoint
+	Errors    map[string]int             // the number of failed requests by endpoint and cause
+	Elapsed   time.Duration
+}
+
+// Percentile Returns the latency below which the given percentage of successful requests to an
+// endpoint were answered.
+func (r Report) Percentile(endpoint string, percent float64) time.Duration {
+	latencies := append([]time.Duration(nil), r.Latencies[endpoint]...)
+	if len(latencies) == 0 {
+		return 0
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	rank := int(percent/100*float64(len(latencies)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(latencies) {
+		rank = len(latencies)
+	}
+	return latencies[rank-1]
+}
+
+// ErrorCount Returns the number of failed requests.
+func (r Report) ErrorCount() int {
+	count := 0
+	for _, n := range r.Errors {
+		count += n
+	}
+	return count
+}
+
+// Write Writes the latency percentiles of each endpoint followed by the errors.
+func (r Report) Write(w io.Writer) error {
+	var out strings.Builder
+	rate := 0.0
+	if r.Elapsed > 0 {
+		rate = float64(r.Requests) / r.Elapsed.Seconds()
+	}
+	fmt.Fprintf(&out, "%d game(s), %d request(s) in %s (%.1f/s), %d error(s)\n\n",
+		r.Games, r.Requests, r.Elapsed.Round(time.Millisecond), rate, r.ErrorCount())
+
+	fmt.Fprintf(&out, "%-8s %8s %10s %10s %10s %10s\n", "endpoint", "ok", "p50", "p90", "p99", "max")
+	for _, endpoint := range []string{EndpointStart, EndpointMove, EndpointEnd} {
+		fmt.Fprintf(&out, "%-8s %8d %10s %10s %10s %10s\n", endpoint, len(r.Latencies[endpoint]),
+			r.Percentile(endpoint, 50).Round(time.Microsecond),
+			r.Percentile(endpoint, 90).Round(time.Microsecond),
+			r.Percentile(endpoint, 99).Round(time.Microsecond),
+			r.Percentile(endpoint, 100).Round(time.Microsecond))
+	}
+
+	if len(r.Errors) > 0 {
+		causes := make([]string, 0, len(r.Errors))
+		for cause := range r.Errors {
+			causes = append(causes, cause)
+		}
+		sort.Strings(causes)
+		fmt.Fprintln(&out)
+		for _, cause := range causes {
+			fmt.Fprintf(&out, "%8d %s\n", r.Errors[cause], cause)
+		}
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Run Plays each game against a snake server, as many at once as the config allows, until every
+// game has been played or the context is done. Each time a game is played it is given its own
+// game ID so that the server treats every copy as a separate game.
+func Run(ctx context.Context, config Config, games []Game) (Report, error) {
+	if len(config.URL) == 0 {
+		return Report{}, errors.New("missing server url")
+	}
+	if config.Concurrency < 1 {
+		return Report{}, fmt.Errorf("concurrency must be at least 1, got %d", config.Concurrency)
+	}
+	if config.Rate < 0 {
+		return Report{}, fmt.Errorf("rate must not be negative, got %v", config.Rate)
+	}
+	repeat := config.Repeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = config.Concurrency
+	defer transport.CloseIdleConnections()
+	runner := &runner{
+		url:    strings.TrimSuffix(config.URL, "/"),
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+		report: Report{
+			Latencies: make(map[string][]time.Duration),
+			Errors:    make(map[string]int),
+		},
+	}
+	if interval := time.Duration(float64(time.Second) / config.Rate); config.Rate > 0 && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		runner.ticks = ticker.C
+	}
+
+	started := time.Now()
+	plays := make(chan Game)
+	var wg sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range plays {
+				runner.play(ctx, game)
+			}
+		}()
+	}
+
+feed:
+	for round := 0; round < repeat; round++ {
+		for _, game := range games {
+			game.ID = fmt.Sprintf("%s-%d", game.ID, round)
+			select {
+			case plays <- game:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(plays)
+	wg.Wait()
+
+	runner.report.Elapsed = time.Since(started)
+	return runner.report, nil
+}
+
+// runner Sends the requests of each game and gathers the results.
+type runner struct {
+	url    string
+	client *http.Client
+	ticks  <-chan time.Time // paces the requests, if there is a rate limit
+	mutex  sync.Mutex
+	report Report
+}
+
+// play Sends every request of a game in order. A failed request does not stop the game because
+// each recorded state stands on its own.
+func (r *runner) play(ctx context.Context, game Game) {
+	for _, request := range game.Requests {
+		if r.ticks != nil {
+			select {
+			case <-r.ticks:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		state := request.State
+		state.Game.ID = game.ID
+		latency, err := r.send(ctx, request.Endpoint, state)
+		r.result(request.Endpoint, latency, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.report.Games += 1
+}
+
+// send Sends a single request and returns how long it took to be answered.
+func (r *runner) send(ctx context.Context, endpoint string, state b.GameState) (time.Duration, error) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return 0, fmt.Errorf("invalid state: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	started := time.Now()
+	response, err := r.client.Do(request)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return 0, errors.New("timeout")
+		}
+		return 0, errors.New("request failed")
+	}
+	defer response.Body.Close()
+	contents, err := io.ReadAll(response.Body)
+	latency := time.Since(started)
+	if err != nil {
+		return 0, errors.New("response failed")
+	}
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status %d", response.StatusCode)
+	}
+
+	if endpoint == EndpointMove {
+		var move b.MoveResponse
+		err = json.Unmarshal(contents, &move)
+		if err != nil {
+			return 0, errors.New("invalid json")
+		}
+		switch move.Move {
+		case b.UP, b.DOWN, b.LEFT, b.RIGHT:
+		default:
+			return 0, fmt.Errorf("invalid move '%s'", move.Move)
+		}
+	}
+	return latency, nil
+}
+
+func (r *runner) result(endpoint string, latency time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.report.Requests += 1
+	if err != nil {
+		r.report.Errors[endpoint+": "+err.Error()] += 1
+		return
+	}
+	r.report.Latencies[endpoint] = append(r.report.Latencies[endpoint], latency)
+}
@@ -0,0 +1,224 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/snacks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recorded Returns the records of a game in which two snakes crawl up opposite sides of the board.
+func recorded(gameID string, turns int) []b.Record {
+	records := make([]b.Record, 0, turns+2)
+	for turn := 0; turn < turns; turn++ {
+		y := 2 + turn%8
+		you := b.Snake{ID: "you", Name: "You", Health: 100, Length: 3,
+			Head: b.Coord{X: 1, Y: y}, Body: []b.Coord{{X: 1, Y: y}, {X: 1, Y: y - 1}, {X: 1, Y: y - 2}}}
+		them := b.Snake{ID: "them", Name: "Them", Health: 100, Length: 3,
+			Head: b.Coord{X: 9, Y: y}, Body: []b.Coord{{X: 9, Y: y}, {X: 9, Y: y - 1}, {X: 9, Y: y - 2}}}
+		state := b.GameState{
+			Game:  b.Game{ID: gameID},
+			Turn:  turn,
+			Board: b.Board{Width: 11, Height: 11, Food: []b.Coord{{X: 5, Y: 5}}, Snakes: []b.Snake{you, them}},
+			You:   you,
+		}
+		if turn == 0 {
+			records = append(records, b.Record{Type: b.RecordStart, State: state})
+		}
+		records = append(records, b.Record{Type: b.RecordMove, State: state, Response: &b.MoveResponse{Move: b.UP}})
+		if turn == turns-1 {
+			records = append(records, b.Record{Type: b.RecordEnd, State: state})
+		}
+	}
+	return records
+}
+
+func endpoints(game Game) []string {
+	endpoints := make([]string, 0, len(game.Requests))
+	for _, request := range game.Requests {
+		endpoints = append(endpoints, request.Endpoint)
+	}
+	return endpoints
+}
+
+func Test_Games(t *testing.T) {
+	games := Games(recorded("game-1", 2))
+	require.Len(t, games, 1)
+	require.Equal(t, "game-1", games[0].ID)
+	require.Equal(t, "you", games[0].SnakeID)
+	require.Equal(t, []string{EndpointStart, EndpointMove, EndpointMove, EndpointEnd}, endpoints(games[0]))
+}
+
+func Test_Games_Imported(t *testing.T) {
+	// Imported games only record moves, from the perspective of every snake
+	you := b.Snake{ID: "you", Head: b.Coord{X: 1, Y: 1}}
+	them := b.Snake{ID: "them", Head: b.Coord{X: 3, Y: 3}}
+	board := b.Board{Width: 5, Height: 5, Snakes: []b.Snake{you, them}}
+	records := []b.Record{
+		{Type: b.RecordMove, State: b.GameState{Game: b.Game{ID: "game-1"}, Turn: 0, Board: board, You: you}},
+		{Type: b.RecordMove, State: b.GameState{Game: b.Game{ID: "game-1"}, Turn: 0, Board: board, You: them}},
+		{Type: b.RecordMove, State: b.GameState{Game: b.Game{ID: "game-1"}, Turn: 1, Board: board, You: you}},
+	}
+	games := Games(records)
+	require.Len(t, games, 2)
+	require.Equal(t, "you", games[0].SnakeID)
+	require.Equal(t, []string{EndpointStart, EndpointMove, EndpointMove, EndpointEnd}, endpoints(games[0]))
+	require.Equal(t, 0, games[0].Requests[0].State.Turn)
+	require.Equal(t, 1, games[0].Requests[3].State.Turn)
+	require.Equal(t, "them", games[1].SnakeID)
+	require.Equal(t, []string{EndpointStart, EndpointMove, EndpointEnd}, endpoints(games[1]))
+}
+
+func Test_Report_Percentile(t *testing.T) {
+	report := Report{Latencies: map[string][]time.Duration{EndpointMove: {}}}
+	for i := 100; i > 0; i-- {
+		report.Latencies[EndpointMove] = append(report.Latencies[EndpointMove], time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 1*time.Millisecond, report.Percentile(EndpointMove, 0))
+	require.Equal(t, 50*time.Millisecond, report.Percentile(EndpointMove, 50))
+	require.Equal(t, 99*time.Millisecond, report.Percentile(EndpointMove, 99))
+	require.Equal(t, 100*time.Millisecond, report.Percentile(EndpointMove, 100))
+	require.Equal(t, time.Duration(0), report.Percentile(EndpointStart, 50))
+
+	// The latencies are left in the order they were recorded
+	require.Equal(t, 100*time.Millisecond, report.Latencies[EndpointMove][0])
+}
+
+func Test_Report_Write(t *testing.T) {
+	report := Report{
+		Games:     1,
+		Requests:  3,
+		Latencies: map[string][]time.Duration{EndpointMove: {time.Millisecond, 3 * time.Millisecond}},
+		Errors:    map[string]int{"/end: status 500": 1},
+		Elapsed:   time.Second,
+	}
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	require.Contains(t, out.String(), "1 game(s), 3 request(s) in 1s (3.0/s), 1 error(s)")
+	require.Contains(t, out.String(), "/move           2        1ms        3ms        3ms        3ms")
+	require.Contains(t, out.String(), "       1 /end: status 500")
+}
+
+// Test_Run Plays many games at once against a real snake. Run with -race to catch unsafe sharing
+// of state between the games.
+func Test_Run(t *testing.T) {
+	snake := snacks.BattleSnake()
+	snake.SetProfiles(mustProfiles(t))
+	server := httptest.NewServer(b.NewSnakeServer(snake, nil, b.Logging{Logger: zerolog.Nop()}).Handler())
+	defer server.Close()
+
+	games := append(Games(recorded("game-1", 12)), Games(recorded("game-2", 8))...)
+	config := Config{URL: server.URL, Concurrency: 16, Repeat: 8, Timeout: 5 * time.Second}
+	report, err := Run(context.Background(), config, games)
+	require.NoError(t, err)
+	require.Empty(t, report.Errors)
+	require.Equal(t, 16, report.Games)
+	require.Equal(t, 8*(14+10), report.Requests)
+	require.Len(t, report.Latencies[EndpointStart], 16)
+	require.Len(t, report.Latencies[EndpointMove], 8*(12+8))
+	require.Len(t, report.Latencies[EndpointEnd], 16)
+}
+
+func mustProfiles(t *testing.T) *snacks.ProfileStore {
+	profiles, err := snacks.NewProfileStore(t.TempDir())
+	require.NoError(t, err)
+	return profiles
+}
+
+func Test_Run_CopiesAreSeparateGames(t *testing.T) {
+	seen := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var state b.GameState
+		err := json.NewDecoder(r.Body).Decode(&state)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path == EndpointStart {
+			seen <- state.Game.ID
+		}
+		_ = json.NewEncoder(w).Encode(b.MoveResponse{Move: b.UP})
+	}))
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{URL: server.URL, Concurrency: 2, Repeat: 3}, Games(recorded("game", 1)))
+	require.NoError(t, err)
+	require.Empty(t, report.Errors)
+	close(seen)
+	ids := make([]string, 0)
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	require.ElementsMatch(t, []string{"game-0", "game-1", "game-2"}, ids)
+}
+
+func Test_Run_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case EndpointStart:
+			w.WriteHeader(http.StatusInternalServerError)
+		case EndpointMove:
+			_ = json.NewEncoder(w).Encode(b.MoveResponse{Move: "sideways"})
+		case EndpointEnd:
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	config := Config{URL: server.URL, Concurrency: 1, Timeout: 50 * time.Millisecond}
+	report, err := Run(context.Background(), config, Games(recorded("game", 2)))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Games)
+	require.Equal(t, 4, report.Requests)
+	require.Equal(t, 4, report.ErrorCount())
+	require.Equal(t, map[string]int{
+		"/start: status 500":             1,
+		"/move: invalid move 'sideways'": 2,
+		"/end: timeout":                  1,
+	}, report.Errors)
+	require.Empty(t, report.Latencies)
+}
+
+func Test_Run_Rate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(b.MoveResponse{Move: b.UP})
+	}))
+	defer server.Close()
+
+	// 10 requests at 100 per second take at least 100ms, however many are sent at once
+	config := Config{URL: server.URL, Concurrency: 4, Rate: 100}
+	report, err := Run(context.Background(), config, Games(recorded("game", 8)))
+	require.NoError(t, err)
+	require.Equal(t, 10, report.Requests)
+	require.GreaterOrEqual(t, report.Elapsed, 90*time.Millisecond)
+}
+
+func Test_Run_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(b.MoveResponse{Move: b.UP})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := Run(ctx, Config{URL: server.URL, Concurrency: 2, Repeat: 100}, Games(recorded("game", 8)))
+	require.NoError(t, err)
+	require.Zero(t, report.Games)
+	require.Zero(t, report.Requests)
+}
+
+func Test_Run_InvalidConfig(t *testing.T) {
+	_, err := Run(context.Background(), Config{Concurrency: 1}, nil)
+	require.EqualError(t, err, "missing server url")
+	_, err = Run(context.Background(), Config{URL: "http://localhost", Concurrency: 0}, nil)
+	require.EqualError(t, err, "concurrency must be at least 1, got 0")
+	_, err = Run(context.Background(), Config{URL: "http://localhost", Concurrency: 1, Rate: -1}, nil)
+	require.EqualError(t, err, "rate must not be negative, got -1")
+}
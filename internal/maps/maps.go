@@ -0,0 +1,309 @@
+package maps
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+)
+
+// Map names
+// https://docs.battlesnake.com/guides/game/maps
+const (
+	Standard   = "standard"
+	Empty      = "empty"
+	ArcadeMaze = "arcade_maze"
+	InnerWall  = "hz_inner_wall"
+	Rings      = "hz_rings"
+	Columns    = "hz_columns"
+	Spiral     = "hz_spiral"
+)
+
+// Map Describes how a game map places hazards and food.
+type Map interface {
+	// Name Returns the name of the map.
+	Name() string
+
+	// Static Returns true if the hazards never change once the game starts.
+	Static() bool
+
+	// Hazards Predicts the hazards that will be on the board at a future turn.
+	Hazards(state b.GameState, turn int) []b.Coord
+
+	// FoodSpawns Returns the only squares where food can appear, or nil if food can appear anywhere.
+	// An empty slice means that food never appears.
+	FoodSpawns(state b.GameState) []b.Coord
+}
+
+var known = map[string]Map{
+	Standard:   &staticMap{name: Standard, food: true},
+	Empty:      &staticMap{name: Empty},
+	ArcadeMaze: &arcadeMazeMap{},
+	InnerWall:  &staticMap{name: InnerWall, food: true, layout: innerWall},
+	Rings:      &staticMap{name: Rings, food: true, layout: rings},
+	Columns:    &staticMap{name: Columns, food: true, layout: columns},
+	Spiral:     &spiralMap{everyNTurns: 3},
+}
+
+// Lookup Returns a known map by name.
+func Lookup(name string) (Map, bool) {
+	m, ok := known[name]
+	return m, ok
+}
+
+// ForGame Returns the map being played. Unknown maps are treated as the standard map.
+func ForGame(state b.GameState) Map {
+	if m, ok := Lookup(state.Game.Map); ok {
+		return m
+	}
+	if m, ok := Lookup(state.Game.Ruleset.Settings.HazardMap); ok {
+		return m
+	}
+	return known[Standard]
+}
+
+// staticMap A map whose hazards are laid out by the size of the board when the game starts and
+// never change.
+type staticMap struct {
+	name   string
+	food   bool                              // food spawns during the game
+	layout func(width, height int) []b.Coord // optional; the hazards for a board size
+}
+
+func (m *staticMap) Name() string {
+	return m.name
+}
+
+func (m *staticMap) Static() bool {
+	return true
+}
+
+func (m *staticMap) Hazards(state b.GameState, _ int) []b.Coord {
+	if m.layout == nil {
+		return []b.Coord{}
+	}
+	return m.layout(state.Board.Width, state.Board.Height)
+}
+
+// FoodSpawns Returns every square outside the hazards, as food is never placed in a hazard.
+func (m *staticMap) FoodSpawns(state b.GameState) []b.Coord {
+	if !m.food {
+		return []b.Coord{} // Food never spawns
+	}
+	if m.layout == nil {
+		return nil
+	}
+	hazards := make(map[b.Coord]bool)
+	for _, hazard := range m.Hazards(state, state.Turn) {
+		hazards[hazard] = true
+	}
+	spawns := make([]b.Coord, 0)
+	for y := 0; y < state.Board.Height; y++ {
+		for x := 0; x < state.Board.Width; x++ {
+			if coord := (b.Coord{X: x, Y: y}); !hazards[coord] {
+				spawns = append(spawns, coord)
+			}
+		}
+	}
+	return spawns
+}
+
+// drawRing Returns the squares of a rectangle drawn in from the edges of the board, as the rules
+// engine draws it. An offset of 1 draws the edges of the board, so the sides of the rectangle run
+// from hOffset-1 to width-hOffset and from vOffset-1 to height-vOffset.
+func drawRing(width, height, hOffset, vOffset int) []b.Coord {
+	left, right := hOffset-1, width-hOffset
+	bottom, top := vOffset-1, height-vOffset
+	coords := make([]b.Coord, 0)
+	if left > right || bottom > top {
+		return coords
+	}
+	for x := left; x <= right; x++ {
+		coords = append(coords, b.Coord{X: x, Y: bottom})
+		if top != bottom {
+			coords = append(coords, b.Coord{X: x, Y: top})
+		}
+	}
+	for y := bottom + 1; y < top; y++ {
+		coords = append(coords, b.Coord{X: left, Y: y})
+		if right != left {
+			coords = append(coords, b.Coord{X: right, Y: y})
+		}
+	}
+	return coords
+}
+
+// innerWall A single ring of hazards one square in from the edges of the board, walling in the middle.
+func innerWall(width, height int) []b.Coord {
+	return drawRing(width, height, 2, 2)
+}
+
+// rings Rings of hazards every other square in from the edges of the board, up to the middle of the
+// board's width.
+func rings(width, height int) []b.Coord {
+	coords := make([]b.Coord, 0)
+	for offset := 2; offset < width/2; offset += 2 {
+		coords = append(coords, drawRing(width, height, offset, offset)...)
+	}
+	return coords
+}
+
+// columns A hazard on every square whose coordinates are both odd.
+func columns(width, height int) []b.Coord {
+	coords := make([]b.Coord, 0)
+	for x := 1; x < width; x += 2 {
+		for y := 1; y < height; y += 2 {
+			coords = append(coords, b.Coord{X: x, Y: y})
+		}
+	}
+	return coords
+}
+
+// arcadeMazeMap A maze whose walls are hazards, played only on a 19x21 board. Every wall is placed
+// when the game starts, so the hazards on the board are the whole maze. Food only spawns at a few
+// fixed squares in the corridors.
+type arcadeMazeMap struct{}
+
+// arcadeMazeFoodSpawns The squares where the rules engine places food in the arcade maze.
+var arcadeMazeFoodSpawns = []b.Coord{
+	{X: 1, Y: 1},
+	{X: 3, Y: 11},
+	{X: 4, Y: 7},
+	{X: 4, Y: 17},
+	{X: 9, Y: 1},
+	{X: 9, Y: 5},
+	{X: 9, Y: 11},
+	{X: 9, Y: 17},
+	{X: 14, Y: 7},
+	{X: 14, Y: 17},
+	{X: 15, Y: 11},
+	{X: 17, Y: 1},
+}
+
+func (m *arcadeMazeMap) Name() string {
+	return ArcadeMaze
+}
+
+func (m *arcadeMazeMap) Static() bool {
+	return true
+}
+
+func (m *arcadeMazeMap) Hazards(state b.GameState, _ int) []b.Coord {
+	return state.Board.Hazards
+}
+
+// FoodSpawns Returns the fixed food squares, unless the maze is being played on a board of another
+// size, where they would not line up with the corridors.
+func (m *arcadeMazeMap) FoodSpawns(state b.GameState) []b.Coord {
+	if state.Board.Width != 19 || state.Board.Height != 21 {
+		return nil
+	}
+	spawns := make([]b.Coord, len(arcadeMazeFoodSpawns))
+	copy(spawns, arcadeMazeFoodSpawns)
+	return spawns
+}
+
+// spiralMap A map whose hazards grow clockwise in a spiral, one square every few turns, from a
+// random square near the center of the board. The spiral starts on the turn that its first
+// square is placed.
+type spiralMap struct {
+	everyNTurns int
+}
+
+func (m *spiralMap) Name() string {
+	return Spiral
+}
+
+func (m *spiralMap) Static() bool {
+	return false
+}
+
+func (m *spiralMap) Hazards(state b.GameState, turn int) []b.Coord {
+	if turn <= state.Turn {
+		return state.Board.Hazards
+	}
+	grid := b.NewGrid(state)
+	grown := turn/m.everyNTurns - state.Turn/m.everyNTurns
+	if len(state.Board.Hazards) == 0 {
+		// The spiral may yet start anywhere near the center, with its first square placed on the
+		// first turn that it grows
+		count := turn / m.everyNTurns
+		if count == 0 {
+			return []b.Coord{}
+		}
+		possible := make([]b.Coord, 0)
+		seen := make(map[b.Coord]bool)
+		for _, center := range m.centers(state) {
+			for _, coord := range spiral(center, grid, count) {
+				if !seen[coord] {
+					seen[coord] = true
+					possible = append(possible, coord)
+				}
+			}
+		}
+		return possible
+	}
+	center, ok := m.center(state, grid)
+	if !ok {
+		return state.Board.Hazards // Not a spiral that can be followed
+	}
+	return spiral(center, grid, len(state.Board.Hazards)+grown)
+}
+
+func (m *spiralMap) FoodSpawns(_ b.GameState) []b.Coord {
+	return nil
+}
+
+// centers Returns the squares where the spiral may start.
+func (m *spiralMap) centers(state b.GameState) []b.Coord {
+	middle := b.Coord{X: state.Board.Width / 2, Y: state.Board.Height / 2}
+	centers := make([]b.Coord, 0, 9)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			centers = append(centers, b.Coord{X: middle.X + dx, Y: middle.Y + dy})
+		}
+	}
+	return centers
+}
+
+// center Returns the hazard that the spiral on the board grew from. Hazards are not relied on to
+// arrive in the order they were placed.
+func (m *spiralMap) center(state b.GameState, grid b.Grid) (b.Coord, bool) {
+	hazards := make(map[b.Coord]bool)
+	for _, hazard := range state.Board.Hazards {
+		hazards[hazard] = true
+	}
+	for _, candidate := range state.Board.Hazards {
+		coords := spiral(candidate, grid, len(hazards))
+		matches := len(coords) == len(hazards)
+		for _, coord := range coords {
+			matches = matches && hazards[coord]
+		}
+		if matches {
+			return candidate, true
+		}
+	}
+	return b.Coord{}, false
+}
+
+// spiral Returns the first squares on the board of a clockwise spiral around a center point.
+func spiral(center b.Coord, grid b.Grid, count int) []b.Coord {
+	coords := make([]b.Coord, 0, count)
+	maxSteps := 2 * (grid.Width + grid.Height)
+	curr := center
+	if grid.InBounds(curr) {
+		coords = append(coords, curr)
+	}
+	for steps := 1; len(coords) < count && steps <= maxSteps; steps++ {
+		moves := []b.Move{b.RIGHT, b.DOWN}
+		if steps%2 == 0 {
+			moves = []b.Move{b.LEFT, b.UP}
+		}
+		for _, move := range moves {
+			for i := 0; i < steps && len(coords) < count; i++ {
+				curr = curr.Move(move)
+				if grid.InBounds(curr) {
+					coords = append(coords, curr)
+				}
+			}
+		}
+	}
+	return coords
+}
@@ -0,0 +1,239 @@
+package maps
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func Test_ForGame(t *testing.T) {
+	state := b.GameState{Game: b.Game{Map: ArcadeMaze}}
+	require.Equal(t, ArcadeMaze, ForGame(state).Name())
+
+	state = b.GameState{Game: b.Game{Map: "unknown"}}
+	require.Equal(t, Standard, ForGame(state).Name())
+
+	state.Game.Ruleset.Settings.HazardMap = Spiral
+	require.Equal(t, Spiral, ForGame(state).Name())
+}
+
+// draw Returns a picture of the squares on a board, top row first.
+func draw(width, height int, coords []b.Coord) []string {
+	rows := make([]string, height)
+	for y := 0; y < height; y++ {
+		row := []byte(strings.Repeat(".", width))
+		for _, coord := range coords {
+			if coord.Y == y {
+				row[coord.X] = '#'
+			}
+		}
+		rows[height-1-y] = string(row)
+	}
+	return rows
+}
+
+func Test_StaticMap_Layouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{name: Standard, expected: []string{
+			".........",
+			".........",
+			".........",
+			".........",
+			".........",
+			".........",
+			".........",
+		}},
+		{name: InnerWall, expected: []string{
+			".........",
+			".#######.",
+			".#.....#.",
+			".#.....#.",
+			".#.....#.",
+			".#######.",
+			".........",
+		}},
+		{name: Rings, expected: []string{
+			".........",
+			".#######.",
+			".#.....#.",
+			".#.....#.",
+			".#.....#.",
+			".#######.",
+			".........",
+		}},
+		{name: Columns, expected: []string{
+			".........",
+			".#.#.#.#.",
+			".........",
+			".#.#.#.#.",
+			".........",
+			".#.#.#.#.",
+			".........",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The layout does not depend on the hazards on the board
+			state := b.GameState{
+				Game:  b.Game{Map: test.name},
+				Board: b.Board{Width: 9, Height: 7, Hazards: []b.Coord{{X: 0, Y: 0}}},
+			}
+			m := ForGame(state)
+			require.True(t, m.Static())
+			require.Equal(t, test.expected, draw(9, 7, m.Hazards(state, 100)))
+		})
+	}
+}
+
+func Test_StaticMap_Rings_Large(t *testing.T) {
+	state := b.GameState{Game: b.Game{Map: Rings}, Board: b.Board{Width: 11, Height: 11}}
+	require.Equal(t, []string{
+		"...........",
+		".#########.",
+		".#.......#.",
+		".#.#####.#.",
+		".#.#...#.#.",
+		".#.#...#.#.",
+		".#.#...#.#.",
+		".#.#####.#.",
+		".#.......#.",
+		".#########.",
+		"...........",
+	}, draw(11, 11, ForGame(state).Hazards(state, 0)))
+}
+
+func Test_FoodSpawns(t *testing.T) {
+	state := b.GameState{Board: b.Board{Width: 5, Height: 5, Food: []b.Coord{{X: 2, Y: 2}}}}
+	standard, _ := Lookup(Standard)
+	require.Nil(t, standard.FoodSpawns(state), "food spawns anywhere")
+	empty, _ := Lookup(Empty)
+	require.NotNil(t, empty.FoodSpawns(state))
+	require.Empty(t, empty.FoodSpawns(state), "food never spawns")
+	maze, _ := Lookup(ArcadeMaze)
+	require.Nil(t, maze.FoodSpawns(state), "the maze does not fit the board")
+
+	// Food is never placed in a hazard
+	columns, _ := Lookup(Columns)
+	spawns := columns.FoodSpawns(state)
+	require.Len(t, spawns, 25-4)
+	require.NotContains(t, spawns, b.Coord{X: 1, Y: 1})
+	require.Contains(t, spawns, b.Coord{X: 2, Y: 2})
+}
+
+func Test_ArcadeMazeMap_FoodSpawns(t *testing.T) {
+	state := b.GameState{
+		Game:  b.Game{Map: ArcadeMaze},
+		Board: b.Board{Width: 19, Height: 21, Food: []b.Coord{{X: 9, Y: 11}}},
+	}
+	require.ElementsMatch(t, []b.Coord{
+		{X: 1, Y: 1}, {X: 9, Y: 1}, {X: 17, Y: 1},
+		{X: 9, Y: 5},
+		{X: 4, Y: 7}, {X: 14, Y: 7},
+		{X: 3, Y: 11}, {X: 9, Y: 11}, {X: 15, Y: 11},
+		{X: 4, Y: 17}, {X: 9, Y: 17}, {X: 14, Y: 17},
+	}, ForGame(state).FoodSpawns(state))
+}
+
+func Test_StaticMap_Coords(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []b.Coord
+	}{
+		{name: InnerWall, expected: []b.Coord{
+			{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 4, Y: 1}, {X: 5, Y: 1},
+			{X: 1, Y: 2}, {X: 5, Y: 2},
+			{X: 1, Y: 3}, {X: 5, Y: 3},
+			{X: 1, Y: 4}, {X: 5, Y: 4},
+			{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 3, Y: 5}, {X: 4, Y: 5}, {X: 5, Y: 5},
+		}},
+		{name: Rings, expected: []b.Coord{
+			{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 4, Y: 1}, {X: 5, Y: 1},
+			{X: 1, Y: 2}, {X: 5, Y: 2},
+			{X: 1, Y: 3}, {X: 5, Y: 3},
+			{X: 1, Y: 4}, {X: 5, Y: 4},
+			{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 3, Y: 5}, {X: 4, Y: 5}, {X: 5, Y: 5},
+		}},
+		{name: Columns, expected: []b.Coord{
+			{X: 1, Y: 1}, {X: 3, Y: 1}, {X: 5, Y: 1},
+			{X: 1, Y: 3}, {X: 3, Y: 3}, {X: 5, Y: 3},
+			{X: 1, Y: 5}, {X: 3, Y: 5}, {X: 5, Y: 5},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := b.GameState{Game: b.Game{Map: test.name}, Board: b.Board{Width: 7, Height: 7}}
+			require.ElementsMatch(t, test.expected, ForGame(state).Hazards(state, 0))
+		})
+	}
+}
+
+func Test_drawRing(t *testing.T) {
+	// An offset of 1 is the edge of the board
+	require.ElementsMatch(t, []b.Coord{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+		{X: 0, Y: 1}, {X: 2, Y: 1},
+		{X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2},
+	}, drawRing(3, 3, 1, 1))
+	require.Equal(t, []b.Coord{{X: 1, Y: 1}}, drawRing(3, 3, 2, 2))
+	require.Empty(t, drawRing(3, 3, 3, 3))
+}
+
+func Test_SpiralMap(t *testing.T) {
+	state := b.GameState{
+		Game: b.Game{Map: Spiral},
+		Turn: 2,
+		Board: b.Board{
+			Width:   5,
+			Height:  5,
+			Hazards: []b.Coord{{X: 2, Y: 2}},
+		},
+	}
+	m := ForGame(state)
+	require.False(t, m.Static())
+	require.Equal(t, state.Board.Hazards, m.Hazards(state, 2))
+	require.Equal(t, []b.Coord{{X: 2, Y: 2}, {X: 3, Y: 2}}, m.Hazards(state, 3))
+	require.Equal(t, []b.Coord{{X: 2, Y: 2}, {X: 3, Y: 2}, {X: 3, Y: 1}, {X: 2, Y: 1}}, m.Hazards(state, 9))
+}
+
+func Test_SpiralMap_NotYetStarted(t *testing.T) {
+	state := b.GameState{Game: b.Game{Map: Spiral}, Board: b.Board{Width: 11, Height: 11}}
+	m := ForGame(state)
+	require.Empty(t, m.Hazards(state, 2))
+
+	// The first square may be placed anywhere near the center
+	possible := m.Hazards(state, 3)
+	require.Len(t, possible, 9)
+	require.Contains(t, possible, b.Coord{X: 5, Y: 5})
+	require.Contains(t, possible, b.Coord{X: 4, Y: 6})
+	require.NotContains(t, possible, b.Coord{X: 3, Y: 5})
+}
+
+func Test_SpiralMap_Unordered(t *testing.T) {
+	// The center need not be the first hazard
+	state := b.GameState{
+		Game:  b.Game{Map: Spiral},
+		Turn:  6,
+		Board: b.Board{Width: 5, Height: 5, Hazards: []b.Coord{{X: 3, Y: 2}, {X: 2, Y: 2}}},
+	}
+	m := ForGame(state)
+	require.Equal(t, []b.Coord{{X: 2, Y: 2}, {X: 3, Y: 2}, {X: 3, Y: 1}}, m.Hazards(state, 9))
+
+	// Hazards that no spiral explains are expected to stay as they are
+	state.Board.Hazards = []b.Coord{{X: 0, Y: 0}, {X: 4, Y: 4}}
+	require.Equal(t, state.Board.Hazards, m.Hazards(state, 9))
+}
+
+func Test_Spiral_OffBoard(t *testing.T) {
+	grid := b.Grid{Width: 3, Height: 3}
+	coords := spiral(b.Coord{X: 0, Y: 0}, grid, 9)
+	require.Len(t, coords, 9)
+	require.ElementsMatch(t, []b.Coord{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+		{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1},
+		{X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2},
+	}, coords)
+}
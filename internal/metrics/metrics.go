@@ -0,0 +1,296 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType The content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets Histogram buckets, in seconds, suited to request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric Any metric that can write itself in the Prometheus text exposition format.
+type metric interface {
+	name() string
+	write(w io.Writer) error
+}
+
+// Registry A collection of metrics served together.
+type Registry struct {
+	mutex   sync.Mutex
+	metrics map[string]metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+// DefaultRegistry The registry that holds the snake's metrics.
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(m metric) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.metrics[m.name()]; exists {
+		panic(fmt.Sprintf("metric '%s' is already registered", m.name()))
+	}
+	r.metrics[m.name()] = m
+}
+
+// Write Writes every metric, ordered by name, in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mutex.Lock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mutex.Unlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name() < metrics[j].name()
+	})
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleMetrics Serves the metrics of the default registry. The error is left to the caller to log,
+// as the response has already begun.
+func HandleMetrics(w http.ResponseWriter, _ *http.Request) error {
+	w.Header().Set("Content-Type", ContentType)
+	return DefaultRegistry.Write(w)
+}
+
+// desc Describes a metric and the labels that partition it.
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+// key Identifies the series for a set of label values.
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric '%s' expects %d label(s), got %d", d.metricName, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d desc) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, kind)
+	return err
+}
+
+// labelPairs Formats label values as '{name="value",...}' including any extra pair.
+func (d desc) labelPairs(key string, extra ...string) string {
+	pairs := make([]string, 0, len(d.labels)+1)
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, d.labels[i], escapeLabel(value)))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter A count that only goes up, partitioned by label values.
+type Counter struct {
+	desc
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter Creates a counter and registers it with the default registry.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return newCounter(DefaultRegistry, name, help, labels...)
+}
+
+func newCounter(registry *Registry, name, help string, labels ...string) *Counter {
+	counter := &Counter{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		values: make(map[string]float64),
+	}
+	if len(labels) == 0 {
+		counter.values[""] = 0
+	}
+	registry.register(counter)
+	return counter
+}
+
+// Inc Adds one to the count for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add Adds to the count for the given label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter '%s' cannot decrease", c.metricName))
+	}
+	key := c.key(labelValues)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values[key] += value
+}
+
+// Value Returns the count for the given label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(key), formatFloat(c.values[key]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram Counts observations in buckets, partitioned by label values.
+type Histogram struct {
+	desc
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // the number of observations in each bucket; not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram Creates a histogram with the given upper bounds and registers it with the default registry.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return newHistogram(DefaultRegistry, name, help, buckets, labels...)
+}
+
+func newHistogram(registry *Registry, name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	histogram := &Histogram{
+		desc:    desc{metricName: name, help: help, labels: labels},
+		buckets: sorted,
+		series:  make(map[string]*histogramSeries),
+	}
+	registry.register(histogram)
+	return histogram
+}
+
+// Observe Records an observation for the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i] += 1
+			break
+		}
+	}
+	series.count += 1
+	series.sum += value
+}
+
+// Count Returns the number of observations for the given label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if series, ok := h.series[key]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(key, "le", formatFloat(bound)), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.metricName, h.labelPairs(key, "le", "+Inf"), series.count,
+			h.metricName, h.labelPairs(key), formatFloat(series.sum),
+			h.metricName, h.labelPairs(key), series.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
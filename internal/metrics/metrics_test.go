@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Counter_Inc(t *testing.T) {
+	registry := NewRegistry()
+	counter := newCounter(registry, "requests_total", "Requests by endpoint.", "endpoint")
+	counter.Inc("/move")
+	counter.Inc("/move")
+	counter.Add(3, "/start")
+	require.Equal(t, 2.0, counter.Value("/move"))
+	require.Equal(t, 3.0, counter.Value("/start"))
+	require.Equal(t, 0.0, counter.Value("/end"))
+}
+
+func Test_Counter_WrongLabels(t *testing.T) {
+	counter := newCounter(NewRegistry(), "requests_total", "Requests by endpoint.", "endpoint")
+	require.Panics(t, func() { counter.Inc() })
+	require.Panics(t, func() { counter.Add(-1, "/move") })
+}
+
+func Test_Registry_Write(t *testing.T) {
+	registry := NewRegistry()
+	latency := newHistogram(registry, "latency_seconds", "Latency.", []float64{1, 0.1}, "snake")
+	errors := newCounter(registry, "errors_total", "Errors by kind.", "endpoint", "kind")
+	noSafeMoves := newCounter(registry, "no_safe_moves_total", "Turns with no safe moves.")
+
+	latency.Observe(0.05, "Battle Snake")
+	latency.Observe(0.5, "Battle Snake")
+	latency.Observe(2, "Battle Snake")
+	errors.Inc("move", KindDecode)
+	errors.Inc(`"quoted"`, KindEncode)
+	noSafeMoves.Inc()
+	require.Equal(t, uint64(3), latency.Count("Battle Snake"))
+
+	var out bytes.Buffer
+	require.NoError(t, registry.Write(&out))
+	require.Equal(t, ""+
+		"# HELP errors_total Errors by kind.\n"+
+		"# TYPE errors_total counter\n"+
+		`errors_total{endpoint="\"quoted\"",kind="encode"} 1`+"\n"+
+		`errors_total{endpoint="move",kind="decode"} 1`+"\n"+
+		"# HELP latency_seconds Latency.\n"+
+		"# TYPE latency_seconds histogram\n"+
+		`latency_seconds_bucket{snake="Battle Snake",le="0.1"} 1`+"\n"+
+		`latency_seconds_bucket{snake="Battle Snake",le="1"} 2`+"\n"+
+		`latency_seconds_bucket{snake="Battle Snake",le="+Inf"} 3`+"\n"+
+		`latency_seconds_sum{snake="Battle Snake"} 2.55`+"\n"+
+		`latency_seconds_count{snake="Battle Snake"} 3`+"\n"+
+		"# HELP no_safe_moves_total Turns with no safe moves.\n"+
+		"# TYPE no_safe_moves_total counter\n"+
+		"no_safe_moves_total 1\n", out.String())
+}
+
+func Test_Registry_DuplicateName(t *testing.T) {
+	registry := NewRegistry()
+	newCounter(registry, "requests_total", "Requests.")
+	require.Panics(t, func() { newCounter(registry, "requests_total", "Requests.") })
+}
+
+func Test_HandleMetrics(t *testing.T) {
+	Requests.Inc("/metrics")
+	w := httptest.NewRecorder()
+	require.NoError(t, HandleMetrics(w, httptest.NewRequest(http.MethodGet, "/metrics", nil)))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	require.True(t, strings.Contains(w.Body.String(), `battlesnake_requests_total{endpoint="/metrics"}`))
+	require.True(t, strings.Contains(w.Body.String(), "# TYPE battlesnake_move_duration_seconds histogram"))
+}
@@ -0,0 +1,48 @@
+package metrics
+
+// The metrics exported by the snake server.
+var (
+	// MoveLatency How long each snake takes to choose a move.
+	MoveLatency = NewHistogram("battlesnake_move_duration_seconds",
+		"Time taken to choose a move.", DefaultBuckets, "snake")
+
+	// Requests The requests received by each endpoint.
+	Requests = NewCounter("battlesnake_requests_total",
+		"Requests received by endpoint.", "endpoint")
+
+	// Errors Requests that could not be decoded or validated, or responses that could not be encoded.
+	Errors = NewCounter("battlesnake_errors_total",
+		"Errors by endpoint and kind; either decode, invalid or encode.", "endpoint", "kind")
+
+	// Panics The panics recovered from the snake by endpoint.
+	Panics = NewCounter("battlesnake_panics_total",
+		"Panics recovered from the snake by endpoint.", "endpoint")
+
+	// GamesStarted The games each snake has started.
+	GamesStarted = NewCounter("battlesnake_games_started_total",
+		"Games started.", "snake")
+
+	// GamesEnded The games each snake has finished.
+	GamesEnded = NewCounter("battlesnake_games_ended_total",
+		"Games ended.", "snake")
+
+	// GameResults The games each snake has won, lost or drawn.
+	GameResults = NewCounter("battlesnake_game_results_total",
+		"Games ended by result; either won, lost or draw.", "snake", "result")
+
+	// NoSafeMoves The turns on which a snake had no safe moves and fell back to its default move.
+	NoSafeMoves = NewCounter("battlesnake_no_safe_moves_total",
+		"Turns with no safe moves.")
+
+	// StrategyLatency How long each strategy takes to score the moves.
+	StrategyLatency = NewHistogram("battlesnake_strategy_duration_seconds",
+		"Time taken by each strategy to score the moves.",
+		[]float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1}, "strategy")
+)
+
+// Error kinds
+const (
+	KindDecode  = "decode"
+	KindInvalid = "invalid"
+	KindEncode  = "encode"
+)
@@ -0,0 +1,304 @@
+package puzzle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Extension The extension of puzzle files.
+const Extension = ".puzzle"
+
+// Puzzle A board position along with the moves that a snake is allowed, or forbidden, to make.
+//
+// A puzzle file starts with a header of 'key: value' lines followed by a blank line and a
+// diagram of the board as understood by battlesnake.ParseBoard. For example,
+//
+//	description: Do not move into the dead end
+//	forbid: right
+//
+//	. . . # #
+//	2 1 H . .
+//	. . . # #
+//
+// The header may contain a description, the moves to allow or forbid, the snakes the puzzle
+// applies to, the turn, the ruleset, the map and the health of your snake.
+//
+// A diagram cannot show everything about a game, such as segments stacked on the same square,
+// hazards beneath snakes or the health of opponents. Instead of the turn, ruleset, map and health,
+// the header may hold the whole game state as JSON with the key 'state'. The diagram is then only
+// a picture of the state and must match it.
+type Puzzle struct {
+	Name        string   // the name of the puzzle file
+	Description string   // why the answer is correct
+	Allow       []b.Move // the only moves allowed; any move is allowed if empty
+	Forbid      []b.Move // the moves that are forbidden
+	Snakes      []string // the snakes that must solve the puzzle; every snake if empty
+	State       b.GameState
+}
+
+// Check Returns an error if a move is not an acceptable answer to the puzzle.
+func (p Puzzle) Check(move b.Move) error {
+	for _, forbidden := range p.Forbid {
+		if move == forbidden {
+			return fmt.Errorf("%s is forbidden", move)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, allowed := range p.Allow {
+		if move == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not one of %v", move, p.Allow)
+}
+
+// AppliesTo Returns true if the named snake must solve the puzzle.
+func (p Puzzle) AppliesTo(snake string) bool {
+	if len(p.Snakes) == 0 {
+		return true
+	}
+	for _, name := range p.Snakes {
+		if strings.EqualFold(name, snake) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse Returns the puzzle described by the contents of a puzzle file.
+func Parse(contents string) (Puzzle, error) {
+	var puzzle Puzzle
+	var full *b.GameState
+	described := make([]string, 0) // the keys that describe part of the game state
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	diagram := strings.Builder{}
+	inHeader := true
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if inHeader && len(text) == 0 {
+			inHeader = false
+			continue
+		}
+		if !inHeader {
+			diagram.WriteString(text + "\n")
+			continue
+		}
+		if strings.HasPrefix(text, "//") {
+			continue // A comment
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return Puzzle{}, fmt.Errorf("expected 'key: value' on line %d", line)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch key {
+		case "state":
+			full = &b.GameState{}
+			err := json.Unmarshal([]byte(value), full)
+			if err != nil {
+				return Puzzle{}, fmt.Errorf("invalid '%s' on line %d: %w", key, line, err)
+			}
+			continue
+		case "ruleset", "map", "turn", "health":
+			described = append(described, key)
+		}
+		err := puzzle.set(key, strings.TrimSpace(value))
+		if err != nil {
+			return Puzzle{}, fmt.Errorf("invalid '%s' on line %d: %w", key, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Puzzle{}, err
+	}
+
+	// The header may set parts of the game state that the diagram does not describe
+	state, err := b.ParseBoard(diagram.String())
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("invalid board: %w", err)
+	}
+	if full != nil {
+		if len(described) > 0 {
+			return Puzzle{}, fmt.Errorf("'%s' cannot be set along with the state", described[0])
+		}
+		if b.Render(state) != b.Render(*full) {
+			return Puzzle{}, fmt.Errorf("the board does not match the state; expected\n%s", b.Render(*full))
+		}
+		puzzle.State = *full
+		return puzzle, nil
+	}
+	state.Game = puzzle.State.Game
+	state.Turn = puzzle.State.Turn
+	if puzzle.State.You.Health > 0 {
+		state.You.Health = puzzle.State.You.Health
+		state.Board.Snakes[0].Health = puzzle.State.You.Health
+	}
+	puzzle.State = state
+	return puzzle, nil
+}
+
+func (p *Puzzle) set(key string, value string) error {
+	var err error
+	switch key {
+	case "description":
+		p.Description = value
+	case "allow":
+		p.Allow, err = parseMoves(value)
+	case "forbid":
+		p.Forbid, err = parseMoves(value)
+	case "snakes":
+		p.Snakes = splitList(value)
+	case "ruleset":
+		p.State.Game.Ruleset.Name = value
+	case "map":
+		p.State.Game.Map = value
+	case "turn":
+		p.State.Turn, err = strconv.Atoi(value)
+	case "health":
+		p.State.You.Health, err = strconv.Atoi(value)
+	default:
+		err = fmt.Errorf("unknown key")
+	}
+	return err
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseMoves(value string) ([]b.Move, error) {
+	moves := make([]b.Move, 0)
+	for _, item := range splitList(value) {
+		move := b.Move(strings.ToLower(item))
+		switch move {
+		case b.UP, b.DOWN, b.LEFT, b.RIGHT:
+			moves = append(moves, move)
+		default:
+			return nil, fmt.Errorf("unknown move '%s'", item)
+		}
+	}
+	return moves, nil
+}
+
+// Format Returns the contents of a puzzle file that describes the puzzle. Unless the puzzle came
+// from a diagram, the whole game state is written so that nothing the diagram leaves out is lost.
+func Format(p Puzzle) string {
+	var sb strings.Builder
+	if len(p.Description) > 0 {
+		sb.WriteString(fmt.Sprintf("description: %s\n", p.Description))
+	}
+	if len(p.Allow) > 0 {
+		sb.WriteString(fmt.Sprintf("allow: %s\n", joinMoves(p.Allow)))
+	}
+	if len(p.Forbid) > 0 {
+		sb.WriteString(fmt.Sprintf("forbid: %s\n", joinMoves(p.Forbid)))
+	}
+	if len(p.Snakes) > 0 {
+		sb.WriteString(fmt.Sprintf("snakes: %s\n", strings.Join(p.Snakes, ", ")))
+	}
+	if describable(p.State) {
+		if len(p.State.Game.Ruleset.Name) > 0 {
+			sb.WriteString(fmt.Sprintf("ruleset: %s\n", p.State.Game.Ruleset.Name))
+		}
+		if len(p.State.Game.Map) > 0 {
+			sb.WriteString(fmt.Sprintf("map: %s\n", p.State.Game.Map))
+		}
+		sb.WriteString(fmt.Sprintf("turn: %d\n", p.State.Turn))
+		if p.State.You.Health > 0 {
+			sb.WriteString(fmt.Sprintf("health: %d\n", p.State.You.Health))
+		}
+	} else {
+		state, err := json.Marshal(p.State)
+		if err == nil {
+			sb.WriteString(fmt.Sprintf("state: %s\n", state))
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(b.Render(p.State))
+	return sb.String()
+}
+
+// describable Returns true if the state is exactly what would be parsed from its diagram and the
+// turn, ruleset, map and health, so it can be written without the whole state.
+func describable(state b.GameState) bool {
+	parsed, err := b.ParseBoard(b.Render(state))
+	if err != nil {
+		return false
+	}
+	parsed.Game = b.Game{Ruleset: b.Ruleset{Name: state.Game.Ruleset.Name}, Map: state.Game.Map}
+	parsed.Turn = state.Turn
+	if state.You.Health > 0 {
+		parsed.You.Health = state.You.Health
+		parsed.Board.Snakes[0].Health = state.You.Health
+	}
+	return reflect.DeepEqual(parsed, state)
+}
+
+func joinMoves(moves []b.Move) string {
+	names := make([]string, 0, len(moves))
+	for _, move := range moves {
+		names = append(names, string(move))
+	}
+	return strings.Join(names, ", ")
+}
+
+// FromRecord Returns a puzzle from a recorded turn that forbids the move that was made.
+// This turns a move that lost a game into a regression test.
+func FromRecord(record b.Record, description string) (Puzzle, error) {
+	if record.Response == nil {
+		return Puzzle{}, fmt.Errorf("turn %d has no recorded move", record.State.Turn)
+	}
+	return Puzzle{
+		Description: description,
+		Forbid:      []b.Move{record.Response.Move},
+		State:       record.State,
+	}, nil
+}
+
+// Load Reads a puzzle file.
+func Load(path string) (Puzzle, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return Puzzle{}, err
+	}
+	puzzle, err := Parse(string(contents))
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("%s: %w", path, err)
+	}
+	puzzle.Name = strings.TrimSuffix(filepath.Base(path), Extension)
+	return puzzle, nil
+}
+
+// LoadDir Reads every puzzle file in a directory.
+func LoadDir(dir string) ([]Puzzle, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	puzzles := make([]Puzzle, 0, len(paths))
+	for _, path := range paths {
+		puzzle, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	return puzzles, nil
+}
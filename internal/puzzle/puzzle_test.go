@@ -0,0 +1,145 @@
+package puzzle
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const example = `description: Do not move into the dead end
+forbid: right
+snakes: SOLO, BATTLE
+ruleset: wrapped
+map: hz_spiral
+turn: 12
+health: 40
+
+. . . # #
+2 1 H . .
+. . . # #
+`
+
+func Test_Parse(t *testing.T) {
+	p, err := Parse(example)
+	require.NoError(t, err)
+	require.Equal(t, "Do not move into the dead end", p.Description)
+	require.Equal(t, []b.Move{b.RIGHT}, p.Forbid)
+	require.Empty(t, p.Allow)
+	require.Equal(t, []string{"SOLO", "BATTLE"}, p.Snakes)
+	require.Equal(t, b.RulesetWrapped, p.State.Game.Ruleset.Name)
+	require.Equal(t, "hz_spiral", p.State.Game.Map)
+	require.Equal(t, 12, p.State.Turn)
+	require.Equal(t, 40, p.State.You.Health)
+	require.Equal(t, 40, p.State.Board.Snakes[0].Health)
+	require.Equal(t, b.Coord{X: 2, Y: 1}, p.State.You.Head)
+	require.Equal(t, 3, p.State.You.Length)
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	_, err := Parse("forbid: sideways\n\nH . .")
+	require.ErrorContains(t, err, "unknown move 'sideways'")
+
+	_, err = Parse("colour: red\n\nH . .")
+	require.ErrorContains(t, err, "unknown key")
+
+	_, err = Parse("description: no board\n\n")
+	require.ErrorContains(t, err, "invalid board")
+}
+
+func Test_Format(t *testing.T) {
+	p, err := Parse(example)
+	require.NoError(t, err)
+	again, err := Parse(Format(p))
+	require.NoError(t, err)
+	require.Equal(t, p, again)
+}
+
+func Test_Check(t *testing.T) {
+	p := Puzzle{Allow: []b.Move{b.UP, b.LEFT}, Forbid: []b.Move{b.LEFT}}
+	require.NoError(t, p.Check(b.UP))
+	require.ErrorContains(t, p.Check(b.LEFT), "forbidden")
+	require.ErrorContains(t, p.Check(b.DOWN), "not one of")
+	require.NoError(t, Puzzle{}.Check(b.DOWN))
+}
+
+func Test_AppliesTo(t *testing.T) {
+	require.True(t, Puzzle{}.AppliesTo("BATTLE"))
+	require.True(t, Puzzle{Snakes: []string{"battle"}}.AppliesTo("BATTLE"))
+	require.False(t, Puzzle{Snakes: []string{"SOLO"}}.AppliesTo("BATTLE"))
+}
+
+func Test_FromRecord(t *testing.T) {
+	state := b.MustParseBoard("H 1 .\n. . .")
+	p, err := FromRecord(b.Record{State: state, Response: &b.MoveResponse{Move: b.DOWN}}, "lost")
+	require.NoError(t, err)
+	require.Equal(t, []b.Move{b.DOWN}, p.Forbid)
+	require.Equal(t, "lost", p.Description)
+
+	_, err = FromRecord(b.Record{State: state}, "lost")
+	require.Error(t, err)
+}
+
+func Test_FromRecord_RoundTrip(t *testing.T) {
+	// The opponent is still stacked after eating, sits in a hazard and is low on health; none of
+	// which the diagram can show
+	state := b.MustParseBoard(`
+		. . # # .
+		. 1 H a a
+		. 2 . A .
+	`)
+	state.Game.ID = "game-1"
+	state.Game.Ruleset = b.Ruleset{Name: b.RulesetRoyale, Version: "v1.2.3", Settings: b.RulesetSettings{
+		HazardDamagePerTurn: 14,
+		Royale:              b.RoyaleSettings{ShrinkEveryNTurns: 25},
+	}}
+	state.Turn = 31
+	opponent := &state.Board.Snakes[1]
+	opponent.Body = append(opponent.Body, opponent.Body[len(opponent.Body)-1])
+	opponent.Length = len(opponent.Body)
+	opponent.Health = 37
+	state.Board.Hazards = append(state.Board.Hazards, opponent.Head)
+	state.You.Health = 62
+	state.Board.Snakes[0].Health = 62
+
+	recorder, err := b.NewRecorder(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, recorder.Record(b.Record{Type: b.RecordMove, State: state, Response: &b.MoveResponse{Move: b.DOWN}}))
+	records, err := recorder.Read("game-1")
+	require.NoError(t, err)
+
+	p, err := FromRecord(records[0], "lost")
+	require.NoError(t, err)
+	again, err := Parse(Format(p))
+	require.NoError(t, err)
+	require.Equal(t, records[0].State, again.State)
+	require.Equal(t, p, again)
+}
+
+func Test_Parse_State(t *testing.T) {
+	state := b.MustParseBoard("H 1 .\n. . .")
+	contents := Format(Puzzle{State: state, Forbid: []b.Move{b.RIGHT}})
+	require.NotContains(t, contents, "state:", "a state the diagram describes is written as a diagram")
+
+	state.Board.Snakes[0].Customizations.Color = "#ff0000"
+	contents = Format(Puzzle{State: state})
+	require.Contains(t, contents, "state:")
+
+	_, err := Parse(strings.Replace(contents, "H 1 .", "H 1 *", 1))
+	require.ErrorContains(t, err, "does not match the state")
+
+	_, err = Parse("turn: 3\n" + contents)
+	require.ErrorContains(t, err, "'turn' cannot be set along with the state")
+}
+
+func Test_LoadDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dead-end"+Extension), []byte(example), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+	puzzles, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, puzzles, 1)
+	require.Equal(t, "dead-end", puzzles[0].Name)
+}
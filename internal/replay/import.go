@@ -0,0 +1,401 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("unknown game format")
+
+// ImportFile Imports a game from a file written by the battlesnake CLI, a game exported from the
+// engine or a game recorded by the snake server.
+func ImportFile(path string) ([]b.Record, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Import(contents)
+}
+
+// Import Detects the format of a game and imports it.
+func Import(contents []byte) ([]b.Record, error) {
+	first := contents
+	if i := bytes.IndexByte(contents, '\n'); i >= 0 {
+		first = contents[:i]
+	}
+	var probe struct {
+		Type   string          `json:"type"`
+		Frames json.RawMessage `json:"Frames"`
+		Board  json.RawMessage `json:"board"`
+	}
+	err := json.Unmarshal(first, &probe)
+	if err != nil {
+		// The engine export is a single document that may span many lines
+		err = json.Unmarshal(contents, &probe)
+		if err != nil || probe.Frames == nil {
+			return nil, ErrUnknownFormat
+		}
+	}
+	switch {
+	case probe.Type != "":
+		return readRecords(contents)
+	case probe.Frames != nil:
+		return ImportEngine(bytes.NewReader(contents))
+	default:
+		return ImportCLI(bytes.NewReader(contents))
+	}
+}
+
+func readRecords(contents []byte) ([]b.Record, error) {
+	records := make([]b.Record, 0)
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	for {
+		var record b.Record
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode record: %w", err)
+		}
+		records = append(records, record)
+	}
+}
+
+// ImportCLI Imports the output of 'battlesnake play --output'. The first line describes the
+// game, each following line describes the board on one turn and the last line names the winner.
+func ImportCLI(r io.Reader) ([]b.Record, error) {
+	var game b.Game
+	boards := make(map[int]b.Board)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var frame struct {
+			ID    string   `json:"id"`
+			Game  *b.Game  `json:"game"`
+			Turn  int      `json:"turn"`
+			Board *b.Board `json:"board"`
+		}
+		err := json.Unmarshal(scanner.Bytes(), &frame)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode line %d: %w", line, err)
+		}
+		switch {
+		case frame.Board != nil:
+			if frame.Game != nil {
+				// The settings may only be described by the first line
+				settings := game.Ruleset.Settings
+				game = *frame.Game
+				if game.Ruleset.Settings == (b.RulesetSettings{}) {
+					game.Ruleset.Settings = settings
+				}
+			}
+			boards[frame.Turn] = *frame.Board
+		case line == 1 && frame.ID != "":
+			err = json.Unmarshal(scanner.Bytes(), &game)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode game: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return toRecords(game, boards), nil
+}
+
+// engineGame A game exported from the engine along with all of its frames.
+type engineGame struct {
+	Game struct {
+		ID           string
+		Width        int
+		Height       int
+		Map          string
+		Source       string
+		SnakeTimeout int
+		Ruleset      map[string]interface{}
+	}
+	Frames []struct {
+		Turn    int
+		Food    []b.Coord
+		Hazards []b.Coord
+		Snakes  []struct {
+			ID       string
+			Name     string
+			Body     []b.Coord
+			Health   int
+			Latency  string
+			Shout    string
+			Squad    string
+			Color    string
+			HeadType string
+			TailType string
+			Death    *struct{}
+		}
+	}
+}
+
+// ImportEngine Imports a game exported from the engine as a single JSON document holding
+// the game and its frames.
+func ImportEngine(r io.Reader) ([]b.Record, error) {
+	var export engineGame
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode engine game: %w", err)
+	}
+
+	game := b.Game{
+		ID:      export.Game.ID,
+		Map:     export.Game.Map,
+		Source:  export.Game.Source,
+		Timeout: export.Game.SnakeTimeout,
+	}
+	if name, ok := export.Game.Ruleset["name"].(string); ok {
+		game.Ruleset.Name = name
+	}
+	game.Ruleset.Settings, err = rulesetSettings(export.Game.Ruleset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ruleset: %w", err)
+	}
+	boards := make(map[int]b.Board)
+	for _, frame := range export.Frames {
+		board := b.Board{
+			Width:   export.Game.Width,
+			Height:  export.Game.Height,
+			Food:    frame.Food,
+			Hazards: frame.Hazards,
+			Snakes:  make([]b.Snake, 0),
+		}
+		for _, s := range frame.Snakes {
+			if s.Death != nil {
+				continue // Only living snakes are on the board
+			}
+			board.Snakes = append(board.Snakes, b.Snake{
+				ID:      s.ID,
+				Name:    s.Name,
+				Health:  s.Health,
+				Body:    s.Body,
+				Latency: s.Latency,
+				Shout:   s.Shout,
+				Squad:   s.Squad,
+				Customizations: b.Customizations{
+					Color: s.Color,
+					Head:  s.HeadType,
+					Tail:  s.TailType,
+				},
+			})
+		}
+		boards[frame.Turn] = board
+	}
+	return toRecords(game, boards), nil
+}
+
+// rulesetSettings Reads the settings of a ruleset exported from the engine. The engine keeps them as
+// flat parameters, whose numbers and booleans may be written as strings, but the settings object
+// that snakes are sent is also understood.
+func rulesetSettings(ruleset map[string]interface{}) (b.RulesetSettings, error) {
+	var settings b.RulesetSettings
+	if nested, ok := ruleset["settings"]; ok {
+		contents, err := json.Marshal(nested)
+		if err == nil {
+			err = json.Unmarshal(contents, &settings)
+		}
+		if err != nil {
+			return b.RulesetSettings{}, fmt.Errorf("invalid settings: %w", err)
+		}
+	}
+
+	flat := params(ruleset)
+	for _, err := range []error{
+		flat.number(&settings.FoodSpawnChance, "foodSpawnChance"),
+		flat.number(&settings.MinimumFood, "minimumFood"),
+		flat.number(&settings.HazardDamagePerTurn, "hazardDamagePerTurn", "damagePerTurn"),
+		flat.text(&settings.HazardMap, "hazardMap"),
+		flat.text(&settings.HazardMapAuthor, "hazardMapAuthor"),
+		flat.number(&settings.Royale.ShrinkEveryNTurns, "shrinkEveryNTurns", "royale.shrinkEveryNTurns"),
+		flat.flag(&settings.Squad.AllowBodyCollisions, "allowBodyCollisions", "squad.allowBodyCollisions"),
+		flat.flag(&settings.Squad.SharedElimination, "sharedElimination", "squad.sharedElimination"),
+		flat.flag(&settings.Squad.SharedHealth, "sharedHealth", "squad.sharedHealth"),
+		flat.flag(&settings.Squad.SharedLength, "sharedLength", "squad.sharedLength"),
+	} {
+		if err != nil {
+			return b.RulesetSettings{}, err
+		}
+	}
+	return settings, nil
+}
+
+// params The flat parameters of a ruleset exported from the engine.
+type params map[string]interface{}
+
+// lookup Returns the value of the first of the keys that is set.
+func (p params) lookup(keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := p[key]; ok && value != nil {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+func (p params) number(target *int, keys ...string) error {
+	key, value, ok := p.lookup(keys)
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case float64:
+		*target = int(v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid '%s': expected a number, got '%s'", key, v)
+		}
+		*target = n
+	default:
+		return fmt.Errorf("invalid '%s': expected a number, got %v", key, v)
+	}
+	return nil
+}
+
+func (p params) flag(target *bool, keys ...string) error {
+	key, value, ok := p.lookup(keys)
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case bool:
+		*target = v
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid '%s': expected true or false, got '%s'", key, v)
+		}
+		*target = parsed
+	default:
+		return fmt.Errorf("invalid '%s': expected true or false, got %v", key, v)
+	}
+	return nil
+}
+
+func (p params) text(target *string, keys ...string) error {
+	key, value, ok := p.lookup(keys)
+	if !ok {
+		return nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("invalid '%s': expected text, got %v", key, value)
+	}
+	*target = text
+	return nil
+}
+
+// toRecords Converts each turn into one record per living snake, seen from that snake's perspective.
+// The move each snake made is inferred from the position of its head on the following turn.
+func toRecords(game b.Game, boards map[int]b.Board) []b.Record {
+	turns := make([]int, 0, len(boards))
+	for turn := range boards {
+		turns = append(turns, turn)
+	}
+	sort.Ints(turns)
+
+	records := make([]b.Record, 0)
+	for _, turn := range turns {
+		board := boards[turn]
+		for i := range board.Snakes {
+			fill(&board.Snakes[i])
+		}
+		for _, you := range board.Snakes {
+			state := b.GameState{Game: game, Turn: turn, Board: board, You: you}
+			record := b.Record{Type: b.RecordMove, State: state}
+			if next, ok := boards[turn+1]; ok {
+				record.Response = inferMove(state, you, next)
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// fill Fills in the fields of a snake that can be derived from its body.
+func fill(snake *b.Snake) {
+	if len(snake.Body) > 0 {
+		snake.Head = snake.Body[0]
+	}
+	snake.Length = len(snake.Body)
+}
+
+func inferMove(state b.GameState, you b.Snake, next b.Board) *b.MoveResponse {
+	for _, snake := range next.Snakes {
+		if snake.ID != you.ID || len(snake.Body) == 0 {
+			continue
+		}
+		grid := b.NewGrid(state)
+		for _, move := range []b.Move{b.UP, b.DOWN, b.LEFT, b.RIGHT} {
+			if grid.Move(you.Head, move) == snake.Body[0] {
+				return &b.MoveResponse{Move: move}
+			}
+		}
+	}
+	return nil
+}
+
+// Perspective Returns only the records seen from the perspective of the named snake.
+func Perspective(records []b.Record, name string) []b.Record {
+	filtered := make([]b.Record, 0)
+	for _, record := range records {
+		if record.State.You.Name == name || record.State.You.ID == name {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// SelectPerspective Returns the records seen from the perspective of the named snake. Without a name,
+// the records must all be seen from one snake, as in games recorded by the snake server. Games
+// imported from the CLI or the engine are seen from every snake, so one of them must be named.
+func SelectPerspective(records []b.Record, name string) ([]b.Record, error) {
+	if len(name) > 0 {
+		filtered := Perspective(records, name)
+		if len(filtered) == 0 {
+			return nil, fmt.Errorf("no turns seen by '%s'; expected one of %s", name, strings.Join(perspectives(records), ", "))
+		}
+		return filtered, nil
+	}
+	if snakes := perspectives(records); len(snakes) > 1 {
+		return nil, fmt.Errorf("the game is seen by %d snakes; choose one of %s", len(snakes), strings.Join(snakes, ", "))
+	}
+	return records, nil
+}
+
+// perspectives Returns the name, or else the ID, of each snake that the records are seen by.
+func perspectives(records []b.Record) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, record := range records {
+		if seen[record.State.You.ID] {
+			continue
+		}
+		seen[record.State.You.ID] = true
+		name := record.State.You.Name
+		if len(name) == 0 {
+			name = record.State.You.ID
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
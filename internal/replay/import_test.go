@@ -0,0 +1,118 @@
+package replay
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_ImportCLI(t *testing.T) {
+	records, err := ImportFile("testdata/cli.jsonl")
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	first := records[0]
+	require.Equal(t, "cli-game", first.State.Game.ID)
+	require.Equal(t, b.RulesetStandard, first.State.Game.Ruleset.Name)
+	require.Equal(t, 15, first.State.Game.Ruleset.Settings.FoodSpawnChance, "the settings on the first line are kept")
+	require.Equal(t, 1, first.State.Game.Ruleset.Settings.MinimumFood)
+	require.Equal(t, 0, first.State.Turn)
+	require.Equal(t, "Snake1", first.State.You.Name)
+	require.Equal(t, b.UP, first.Response.Move)
+
+	second := records[1]
+	require.Equal(t, "Snake2", second.State.You.Name)
+	require.Equal(t, b.RIGHT, second.Response.Move)
+
+	// The move on the last turn is unknown
+	require.Nil(t, records[3].Response)
+}
+
+func Test_ImportEngine(t *testing.T) {
+	records, err := ImportFile("testdata/engine.json")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	first := records[0]
+	require.Equal(t, "engine-game", first.State.Game.ID)
+	require.True(t, first.State.Game.Ruleset.IsWrapped())
+	require.Equal(t, b.RulesetSettings{
+		FoodSpawnChance:     15,
+		MinimumFood:         1,
+		HazardDamagePerTurn: 14,
+		Royale:              b.RoyaleSettings{ShrinkEveryNTurns: 25},
+		Squad:               b.SquadSettings{AllowBodyCollisions: true},
+	}, first.State.Game.Ruleset.Settings)
+	require.Equal(t, 5, first.State.Board.Width)
+	require.Equal(t, b.Coord{X: 0, Y: 1}, first.State.You.Head)
+	require.Equal(t, 2, first.State.You.Length)
+	require.Equal(t, b.LEFT, first.Response.Move)
+
+	// Dead snakes are removed from the board
+	require.Len(t, records[2].State.Board.Snakes, 1)
+}
+
+func Test_rulesetSettings(t *testing.T) {
+	// The settings object that snakes are sent is understood too
+	settings, err := rulesetSettings(map[string]interface{}{
+		"name":     "royale",
+		"settings": map[string]interface{}{"hazardDamagePerTurn": 14, "royale": map[string]interface{}{"shrinkEveryNTurns": 25}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 14, settings.HazardDamagePerTurn)
+	require.Equal(t, 25, settings.Royale.ShrinkEveryNTurns)
+
+	settings, err = rulesetSettings(map[string]interface{}{"minimumFood": 2.0, "squad.sharedLength": true})
+	require.NoError(t, err)
+	require.Equal(t, 2, settings.MinimumFood)
+	require.True(t, settings.Squad.SharedLength)
+
+	_, err = rulesetSettings(map[string]interface{}{"damagePerTurn": "lots"})
+	require.EqualError(t, err, "invalid 'damagePerTurn': expected a number, got 'lots'")
+	_, err = rulesetSettings(map[string]interface{}{"allowBodyCollisions": "maybe"})
+	require.EqualError(t, err, "invalid 'allowBodyCollisions': expected true or false, got 'maybe'")
+}
+
+func Test_Import_Recorded(t *testing.T) {
+	recorder, err := b.NewRecorder(t.TempDir())
+	require.NoError(t, err)
+	state := b.GameState{Game: b.Game{ID: "recorded"}, You: b.Snake{Name: "Snake1"}}
+	require.NoError(t, recorder.Record(b.Record{Type: b.RecordMove, State: state, Response: &b.MoveResponse{Move: b.UP}}))
+
+	records, err := ImportFile(recorder.Path("recorded"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, b.UP, records[0].Response.Move)
+}
+
+func Test_Import_Unknown(t *testing.T) {
+	_, err := Import([]byte("not a game"))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func Test_Perspective(t *testing.T) {
+	records, err := ImportFile("testdata/cli.jsonl")
+	require.NoError(t, err)
+	records = Perspective(records, "Snake2")
+	require.Len(t, records, 2)
+	require.Equal(t, "b", records[0].State.You.ID)
+}
+
+func Test_SelectPerspective(t *testing.T) {
+	records, err := ImportFile("testdata/cli.jsonl")
+	require.NoError(t, err)
+
+	// Imported games are seen by every snake, so one must be chosen
+	_, err = SelectPerspective(records, "")
+	require.EqualError(t, err, "the game is seen by 2 snakes; choose one of Snake1, Snake2")
+	selected, err := SelectPerspective(records, "Snake2")
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	_, err = SelectPerspective(records, "Snake3")
+	require.EqualError(t, err, "no turns seen by 'Snake3'; expected one of Snake1, Snake2")
+
+	// Recorded games are seen by one snake
+	selected, err = SelectPerspective(Perspective(records, "Snake1"), "")
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+}
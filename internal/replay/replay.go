@@ -0,0 +1,54 @@
+package replay
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+)
+
+type snake interface {
+	Evaluate(state b.GameState) b.Evaluation
+}
+
+// Difference A turn where the replayed snake chose a different move than the recorded snake.
+type Difference struct {
+	Turn     int
+	State    b.GameState
+	Recorded b.Evaluation
+	Replayed b.Evaluation
+}
+
+// Result The outcome of replaying a recorded game.
+type Result struct {
+	GameID      string
+	Turns       int // the number of turns replayed
+	Differences []Difference
+}
+
+// Replay Feeds each recorded move to a snake and reports the turns where it chose differently.
+func Replay(snake snake, records []b.Record) Result {
+	result := Result{
+		Differences: make([]Difference, 0),
+	}
+	for _, record := range records {
+		if record.Type != b.RecordMove || record.Response == nil {
+			continue
+		}
+		result.GameID = record.State.Game.ID
+		result.Turns += 1
+
+		replayed := snake.Evaluate(record.State)
+		if replayed.Response.Move == record.Response.Move {
+			continue
+		}
+		result.Differences = append(result.Differences, Difference{
+			Turn:  record.State.Turn,
+			State: record.State,
+			Recorded: b.Evaluation{
+				Response:    *record.Response,
+				Scores:      record.Scores,
+				Explanation: record.Explanation,
+			},
+			Replayed: replayed,
+		})
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package replay
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// alwaysSnake A snake that always makes the same move.
+type alwaysSnake struct {
+	move b.Move
+}
+
+func (a alwaysSnake) Evaluate(_ b.GameState) b.Evaluation {
+	return b.Evaluation{Response: b.MoveResponse{Move: a.move}}
+}
+
+func record(turn int, move b.Move) b.Record {
+	return b.Record{
+		Type:     b.RecordMove,
+		State:    b.GameState{Game: b.Game{ID: "game-1"}, Turn: turn},
+		Response: &b.MoveResponse{Move: move},
+		Scores:   map[string]map[b.Move]int{"strategy": {move: 1}},
+	}
+}
+
+func Test_Replay(t *testing.T) {
+	records := []b.Record{
+		{Type: b.RecordStart},
+		record(0, b.UP),
+		record(1, b.LEFT),
+		record(2, b.UP),
+		{Type: b.RecordEnd},
+	}
+	result := Replay(alwaysSnake{move: b.UP}, records)
+	require.Equal(t, "game-1", result.GameID)
+	require.Equal(t, 3, result.Turns)
+	require.Len(t, result.Differences, 1)
+	require.Equal(t, 1, result.Differences[0].Turn)
+	require.Equal(t, b.LEFT, result.Differences[0].Recorded.Response.Move)
+	require.Equal(t, 1, result.Differences[0].Recorded.Scores["strategy"][b.LEFT])
+	require.Equal(t, b.UP, result.Differences[0].Replayed.Response.Move)
+}
@@ -0,0 +1,379 @@
+package snacks
+
+import (
+	"fmt"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tendency How an opponent tends to move.
+type Tendency string
+
+const (
+	FoodSeeking Tendency = "food-seeking" // moves toward the closest food
+	Aggressive  Tendency = "aggressive"   // moves toward the closest head of another snake
+	WallHugging Tendency = "wall-hugging" // moves along the walls
+	Random      Tendency = "random"       // moves in no way that can be told apart from chance
+)
+
+// tendencies The tendencies that can be recognized from how an opponent moves.
+var tendencies = []Tendency{FoodSeeking, Aggressive, WallHugging}
+
+// OpponentModel What has been learnt of an opponent from the moves it has made.
+type OpponentModel struct {
+	ID             string
+	Name           string
+	Customizations b.Customizations
+	Observations   int                  // the number of moves observed
+	matched        map[Tendency]float64 // the moves that were consistent with each tendency
+	expected       map[Tendency]float64 // the moves that would be consistent with each tendency by chance
+	latencyMS      int64                // the total latency observed
+	latencySamples int
+	prior          *OpponentModel // optional; what was known of the opponent before the game
+}
+
+func newOpponentModel(id string) *OpponentModel {
+	return &OpponentModel{
+		ID:       id,
+		matched:  make(map[Tendency]float64),
+		expected: make(map[Tendency]float64),
+	}
+}
+
+// observeLatency Learns how long the opponent took to make its last move.
+func (m *OpponentModel) observeLatency(opponent b.Snake) {
+	latency, err := strconv.ParseInt(opponent.Latency, 10, 64)
+	if err != nil || latency <= 0 {
+		return // the engine reports no latency on the first turn
+	}
+	m.latencyMS += latency
+	m.latencySamples += 1
+}
+
+// observe Learns from a move the opponent made.
+func (m *OpponentModel) observe(before b.GameState, opponent b.Snake, move b.Move) {
+	candidates := candidateMoves(before, opponent)
+	if !containsMove(candidates, move) {
+		return // the opponent had no real choice
+	}
+	m.Observations += 1
+	for _, tendency := range tendencies {
+		consistent := consistentMoves(tendency, before, opponent, candidates)
+		if containsMove(consistent, move) {
+			m.matched[tendency] += 1
+		}
+		m.expected[tendency] += float64(len(consistent)) / float64(len(candidates))
+	}
+}
+
+// Strength How strongly the opponent shows a tendency, from 0 when its moves are no more consistent
+// with the tendency than chance, to 1 when every move is. An opponent is random to the extent that
+// it shows no other tendency.
+func (m *OpponentModel) Strength(tendency Tendency) float64 {
+	if tendency == Random {
+		strongest := 0.0
+		for _, t := range tendencies {
+			if strength := m.Strength(t); strength > strongest {
+				strongest = strength
+			}
+		}
+		return 1 - strongest
+	}
+	excess := m.matched[tendency] - m.expected[tendency]
+	possible := float64(m.Observations) - m.expected[tendency]
+	if excess <= 0 || possible <= 0 {
+		return 0
+	}
+	if excess > possible {
+		return 1
+	}
+	return excess / possible
+}
+
+// Tendency Returns the tendency that best explains the opponent's moves.
+func (m *OpponentModel) Tendency() Tendency {
+	best, bestStrength := Random, m.Strength(Random)
+	for _, tendency := range tendencies {
+		if strength := m.Strength(tendency); strength > bestStrength {
+			best, bestStrength = tendency, strength
+		}
+	}
+	return best
+}
+
+// Predict Returns the probability of each move the opponent could safely make next. Each tendency
+// spreads its share of the probability evenly over the moves consistent with it.
+func (m *OpponentModel) Predict(state b.GameState, opponent b.Snake) map[b.Move]float64 {
+	predictions := make(map[b.Move]float64)
+	candidates := candidateMoves(state, opponent)
+	if len(candidates) == 0 {
+		return predictions
+	}
+	total := m.Strength(Random)
+	for _, tendency := range tendencies {
+		total += m.Strength(tendency)
+	}
+	spread := func(share float64, moves []b.Move) {
+		if len(moves) == 0 {
+			moves = candidates
+		}
+		for _, move := range moves {
+			predictions[move] += share / float64(len(moves))
+		}
+	}
+	spread(m.Strength(Random)/total, candidates)
+	for _, tendency := range tendencies {
+		if strength := m.Strength(tendency); strength > 0 {
+			spread(strength/total, consistentMoves(tendency, state, opponent, candidates))
+		}
+	}
+	return predictions
+}
+
+// String Describes the model, like 'food-seeking (0.75) after 12 move(s)'.
+func (m *OpponentModel) String() string {
+	tendency := m.Tendency()
+	return fmt.Sprintf("%s (%.2f) after %d move(s)", tendency, m.Strength(tendency), m.Observations)
+}
+
+// candidateMoves Returns the moves a snake can make without leaving the board or hitting a body.
+func candidateMoves(state b.GameState, snake b.Snake) []b.Move {
+	grid := b.NewGrid(state)
+	occupied := make(map[b.Coord]bool)
+	for _, other := range state.Board.Snakes {
+		for i, part := range other.Body {
+			isTail := i > 0 && i == len(other.Body)-1
+			if isTail && part != other.Body[i-1] {
+				continue // the tail moves out of the way unless the snake just ate
+			}
+			occupied[part] = true
+		}
+	}
+	candidates := make([]b.Move, 0, len(allMoves))
+	for _, move := range allMoves {
+		next := grid.Move(snake.Head, move)
+		if grid.InBounds(next) && !occupied[next] {
+			candidates = append(candidates, move)
+		}
+	}
+	return candidates
+}
+
+// consistentMoves Returns the candidate moves that a snake with a tendency would make.
+func consistentMoves(tendency Tendency, state b.GameState, snake b.Snake, candidates []b.Move) []b.Move {
+	grid := b.NewGrid(state)
+	var targets []b.Coord
+	switch tendency {
+	case FoodSeeking:
+		targets = state.Board.Food
+	case Aggressive:
+		for _, other := range state.Board.Snakes {
+			if other.ID != snake.ID {
+				targets = append(targets, other.Head)
+			}
+		}
+	case WallHugging:
+		consistent := make([]b.Move, 0)
+		if grid.Wrapped {
+			return consistent // there are no walls
+		}
+		for _, move := range candidates {
+			next := grid.Move(snake.Head, move)
+			if next.X == 0 || next.Y == 0 || next.X == grid.Width-1 || next.Y == grid.Height-1 {
+				consistent = append(consistent, move)
+			}
+		}
+		return consistent
+	}
+
+	// Moves toward the closest target
+	consistent := make([]b.Move, 0)
+	closest := closestDistance(grid, snake.Head, targets)
+	for _, move := range candidates {
+		if closestDistance(grid, grid.Move(snake.Head, move), targets) < closest {
+			consistent = append(consistent, move)
+		}
+	}
+	return consistent
+}
+
+// closestDistance Returns the distance to the closest target, or -1 if there are none.
+func closestDistance(grid b.Grid, from b.Coord, targets []b.Coord) int {
+	closest := -1
+	for _, target := range targets {
+		if dist := grid.DistanceTo(from, target); closest < 0 || dist < closest {
+			closest = dist
+		}
+	}
+	return closest
+}
+
+func containsMove(moves []b.Move, move b.Move) bool {
+	for _, m := range moves {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+// opponentsKey The session key of the opponent models.
+const opponentsKey = "opponents"
+
+// Opponents Models every opponent in a game from the turns seen so far.
+type Opponents struct {
+	mutex    sync.Mutex
+	models   map[string]*OpponentModel
+	lastTurn int // the latest turn observed
+}
+
+func newOpponents() *Opponents {
+	return &Opponents{
+		models:   make(map[string]*OpponentModel),
+		lastTurn: -1,
+	}
+}
+
+// opponentsOf Returns the opponent models kept in a session, brought up to date with the current turn.
+func opponentsOf(session *Session, state b.GameState) *Opponents {
+	value, _ := session.Get(opponentsKey)
+	opponents, ok := value.(*Opponents)
+	if !ok {
+		opponents = newOpponents()
+		session.Set(opponentsKey, opponents)
+	}
+	if previous, ok := session.Previous(); ok {
+		opponents.observe(previous, state)
+	}
+	return opponents
+}
+
+// seed Starts with a model of an opponent, such as one built from earlier games.
+func (o *Opponents) seed(model *OpponentModel) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.models[model.ID] = model
+}
+
+// identify Remembers who each opponent is, as not every opponent survives to the end of the game.
+func (o *Opponents) identify(state b.GameState) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	for _, opponent := range state.Board.Snakes {
+		if opponent.ID != state.You.ID {
+			model := o.model(opponent.ID)
+			model.Name = opponent.Name
+			model.Customizations = opponent.Customizations
+		}
+	}
+}
+
+// all Returns the model of every opponent.
+func (o *Opponents) all() []*OpponentModel {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	models := make([]*OpponentModel, 0, len(o.models))
+	for _, model := range o.models {
+		models = append(models, model)
+	}
+	return models
+}
+
+// observe Learns from the moves each opponent made between two turns. Each turn is only learnt from once.
+func (o *Opponents) observe(before b.GameState, after b.GameState) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if after.Turn <= o.lastTurn || after.Turn <= before.Turn {
+		return
+	}
+	o.lastTurn = after.Turn
+	grid := b.NewGrid(after)
+	for _, opponent := range after.Board.Snakes {
+		if opponent.ID == after.You.ID {
+			continue
+		}
+		o.model(opponent.ID).observeLatency(opponent)
+		for _, previous := range before.Board.Snakes {
+			if previous.ID != opponent.ID {
+				continue
+			}
+			for _, move := range allMoves {
+				if grid.Move(previous.Head, move) == opponent.Head {
+					o.model(opponent.ID).observe(before, previous, move)
+				}
+			}
+		}
+	}
+}
+
+// Model Returns what has been learnt of an opponent.
+func (o *Opponents) Model(id string) *OpponentModel {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.model(id)
+}
+
+func (o *Opponents) model(id string) *OpponentModel {
+	model, ok := o.models[id]
+	if !ok {
+		model = newOpponentModel(id)
+		o.models[id] = model
+	}
+	return model
+}
+
+// likelySquare A square that a snake may move to, along with the chance that it does.
+type likelySquare struct {
+	square b.Coord
+	chance float64
+}
+
+// likelySquares Returns where a snake is likely to be next turn, as predicted from how it has moved
+// so far in the game. Without that history, or if it has no safe move, the snake is taken to stay
+// where its head is now.
+func (o *Opponents) likelySquares(state b.GameState, snake b.Snake) []likelySquare {
+	model := o.Model(snake.ID)
+	if model.Observations == 0 {
+		return []likelySquare{{square: snake.Head, chance: 1}}
+	}
+	grid := b.NewGrid(state)
+	predictions := model.Predict(state, snake)
+	squares := make([]likelySquare, 0, len(predictions))
+	for _, move := range allMoves {
+		if chance := predictions[move]; chance > 0 {
+			squares = append(squares, likelySquare{square: grid.Move(snake.Head, move), chance: chance})
+		}
+	}
+	if len(squares) == 0 {
+		return []likelySquare{{square: snake.Head, chance: 1}}
+	}
+	return squares
+}
+
+// formatPredictions Formats predictions in move order, like '⭡ 0.50 ⭠ 0.50'.
+func formatPredictions(predictions map[b.Move]float64) string {
+	moves := make([]b.Move, 0, len(predictions))
+	for move := range predictions {
+		moves = append(moves, move)
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moveIndex(moves[i]) < moveIndex(moves[j])
+	})
+	parts := make([]string, 0, len(moves))
+	for _, move := range moves {
+		parts = append(parts, fmt.Sprintf("%s %.2f", move, predictions[move]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func moveIndex(move b.Move) int {
+	for i, m := range moveOrder {
+		if m == move {
+			return i
+		}
+	}
+	return len(moveOrder)
+}
@@ -0,0 +1,234 @@
+package snacks
+
+import (
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// chasingFood A game where an opponent heads straight up the middle of the board for the food,
+// one square a turn, while you wait in the corner.
+func chasingFood(turn int) battlesnake.GameState {
+	return chasingFoodOn(turn, chasingFoodTurns[turn])
+}
+
+var chasingFoodTurns = []string{`
+	. . . * . . .
+	. . . . . . .
+	. . . . . . .
+	. . . . . . .
+	. . . A . . .
+	. . . a . . .
+	H . . a . . .
+`, `
+	. . . * . . .
+	. . . . . . .
+	. . . . . . .
+	. . . A . . .
+	. . . a . . .
+	. . . a . . .
+	H . . . . . .
+`, `
+	. . . * . . .
+	. . . . . . .
+	. . . A . . .
+	. . . a . . .
+	. . . a . . .
+	. . . . . . .
+	H . . . . . .
+`, `
+	. . . * . . .
+	. . . A . . .
+	. . . a . . .
+	. . . a . . .
+	. . . . . . .
+	. . . . . . .
+	H . . . . . .
+`, `
+	. . . A . . .
+	. . . a . . .
+	. . . a . . .
+	. . . . . . .
+	. . . . . . .
+	. . . . . . .
+	H . . . . . .
+`}
+
+// chasingFoodOn Returns a board drawn as a turn of the game in chasingFood.
+func chasingFoodOn(turn int, diagram string) battlesnake.GameState {
+	state := battlesnake.MustParseBoard(diagram)
+	state.Game.ID = "game-1"
+	state.Turn = turn
+	state.Board.Snakes[1].Name = "Hungry"
+	state.Board.Snakes[1].Latency = "50"
+	return state
+}
+
+func Test_OpponentModel_NoHistory(t *testing.T) {
+	state := chasingFood(0)
+	model := newOpponentModel("a")
+	require.Equal(t, Random, model.Tendency())
+	require.Equal(t, 1.0, model.Strength(Random))
+
+	// Every move that would not hit a body is equally likely
+	predictions := model.Predict(state, state.Board.Snakes[1])
+	require.Len(t, predictions, 3)
+	for _, move := range []battlesnake.Move{battlesnake.UP, battlesnake.LEFT, battlesnake.RIGHT} {
+		require.InDelta(t, 1.0/3, predictions[move], 0.001)
+	}
+}
+
+func Test_Opponents_FoodSeeking(t *testing.T) {
+	opponents := newOpponents()
+	for turn := 1; turn <= 3; turn++ {
+		opponents.observe(chasingFood(turn-1), chasingFood(turn))
+	}
+	// Each turn is only learnt from once
+	opponents.observe(chasingFood(2), chasingFood(3))
+
+	model := opponents.Model("a")
+	require.Equal(t, 3, model.Observations)
+	require.Equal(t, FoodSeeking, model.Tendency())
+	require.Equal(t, 1.0, model.Strength(FoodSeeking))
+	require.Equal(t, 0.0, model.Strength(Aggressive))
+	require.Equal(t, 0.0, model.Strength(WallHugging))
+	require.Equal(t, "food-seeking (1.00) after 3 move(s)", model.String())
+
+	state := chasingFood(3)
+	require.Equal(t, map[battlesnake.Move]float64{
+		battlesnake.UP:    1.0,
+		battlesnake.LEFT:  0.0,
+		battlesnake.RIGHT: 0.0,
+	}, model.Predict(state, state.Board.Snakes[1]))
+}
+
+func Test_OpponentModel_WallHugging(t *testing.T) {
+	model := newOpponentModel("a")
+	for turn := 0; turn < 4; turn++ {
+		// Runs up the left wall with food off to the right
+		state := chasingFood(turn)
+		opponent := &state.Board.Snakes[1]
+		opponent.Head = battlesnake.Coord{X: 0, Y: 2 + turn}
+		opponent.Body = []battlesnake.Coord{opponent.Head, {X: 0, Y: 1 + turn}, {X: 0, Y: turn}}
+		state.Board.Food = []battlesnake.Coord{{X: 6, Y: 2 + turn}}
+		state.Board.Snakes[0].Head = battlesnake.Coord{X: 6, Y: 2 + turn}
+		model.observe(state, *opponent, battlesnake.UP)
+	}
+	require.Equal(t, WallHugging, model.Tendency())
+}
+
+func Test_AvoidLikelyHeadToHead(t *testing.T) {
+	state := chasingFoodOn(3, `
+		. . . * H 1 2
+		. . . A . . .
+		. . . a . . .
+		. . . a . . .
+		. . . . . . .
+		. . . . . . .
+		. . . . . . .
+	`)
+
+	// Without history every move the opponent could make is equally likely
+	scorecard := NewScorecard(state)
+	AvoidLikelyHeadToHead{weight: 30}.move(state, scorecard)
+	explanation := scorecard.Explain()
+	contributions := explanation.Scores()["avoid-likely-head-to-head"]
+	require.Equal(t, 20, contributions[battlesnake.LEFT])
+	require.Equal(t, 20, contributions[battlesnake.DOWN])
+
+	// Having seen it chase food, the opponent is expected to move up to the food
+	snake := &StrategyDrivenSnake{strategies: []strategy{&StayInBounds{}, &NoCollisions{}, &AvoidLikelyHeadToHead{weight: 30}}}
+	for turn := 0; turn < 3; turn++ {
+		snake.Evaluate(chasingFood(turn))
+	}
+	evaluation := snake.Evaluate(state)
+	require.Equal(t, 0, evaluation.Scores["avoid-likely-head-to-head"][battlesnake.LEFT])
+	require.Equal(t, 30, evaluation.Scores["avoid-likely-head-to-head"][battlesnake.DOWN])
+	require.Equal(t, battlesnake.DOWN, evaluation.Response.Move)
+}
+
+// chasedFood Returns the opponents having seen the opponent in chasingFood head for the food.
+func chasedFood() *Opponents {
+	opponents := newOpponents()
+	for turn := 1; turn <= 3; turn++ {
+		opponents.observe(chasingFood(turn-1), chasingFood(turn))
+	}
+	return opponents
+}
+
+func Test_AvoidBiggerSnakes_Predicted(t *testing.T) {
+	// The opponent is below you now, but is expected to move up beside you to the food
+	state := chasingFoodOn(3, `
+		. . . * H 1 2
+		. . . A . . .
+		. . . a . . .
+		. . . a . . .
+		. . . . . . .
+		. . . . . . .
+		. . . . . . .
+	`)
+	strategy := AvoidBiggerSnakes{weight: 1}
+
+	scorecard := NewScorecard(state)
+	strategy.avoid(state, newOpponents(), scorecard)
+	explanation := scorecard.Explain()
+	contributions := explanation.Scores()["avoid-bigger-snakes"]
+	require.Greater(t, contributions[battlesnake.UP], 0)
+	require.Equal(t, 0, contributions[battlesnake.DOWN])
+
+	scorecard = NewScorecard(state)
+	strategy.avoid(state, chasedFood(), scorecard)
+	explanation = scorecard.Explain()
+	contributions = explanation.Scores()["avoid-bigger-snakes"]
+	require.Equal(t, 0, contributions[battlesnake.UP])
+	require.Greater(t, contributions[battlesnake.DOWN], 0)
+	require.Greater(t, contributions[battlesnake.RIGHT], 0)
+}
+
+func Test_AttackSmallerSnakes_Predicted(t *testing.T) {
+	// The opponent is below you now, but is expected to move up beside you to the food
+	state := chasingFoodOn(3, `
+		. . . * H 1 2
+		. . . A . . 3
+		. . . a . . .
+		. . . a . . .
+		. . . . . . .
+		. . . . . . .
+		. . . . . . .
+	`)
+	strategy := AttackSmallerSnakes{weight: 1}
+
+	scorecard := NewScorecard(state)
+	strategy.attack(state, newOpponents(), scorecard)
+	explanation := scorecard.Explain()
+	contributions := explanation.Scores()["attack-smaller-snakes"]
+	require.Greater(t, contributions[battlesnake.DOWN], 0)
+	require.Equal(t, 0, contributions[battlesnake.UP])
+
+	scorecard = NewScorecard(state)
+	strategy.attack(state, chasedFood(), scorecard)
+	explanation = scorecard.Explain()
+	contributions = explanation.Scores()["attack-smaller-snakes"]
+	require.Equal(t, 0, contributions[battlesnake.DOWN])
+	require.Greater(t, contributions[battlesnake.UP], 0)
+	require.Greater(t, contributions[battlesnake.LEFT], 0)
+}
+
+func Test_AttackSmallerSnakes_Session(t *testing.T) {
+	// The strategy learns from the session how the opponent moves
+	snake := &StrategyDrivenSnake{strategies: []strategy{&AttackSmallerSnakes{weight: 1}}}
+	for turn := 0; turn < 3; turn++ {
+		snake.Evaluate(chasingFood(turn))
+	}
+	evaluation := snake.Evaluate(chasingFoodOn(3, `
+		. . . * H 1 2
+		. . . A . . 3
+		. . . a . . .
+		. . . a . . .
+		. . . . . . .
+		. . . . . . .
+		. . . . . . .
+	`))
+	require.Equal(t, 0, evaluation.Scores["attack-smaller-snakes"][battlesnake.DOWN])
+	require.Greater(t, evaluation.Scores["attack-smaller-snakes"][battlesnake.UP], 0)
+}
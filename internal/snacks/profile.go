@@ -0,0 +1,206 @@
+package snacks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// maxPriorObservations The most moves that what is known of an opponent from earlier games counts
+// for. This keeps a long history from drowning out how the opponent plays in the current game.
+const maxPriorObservations = 50
+
+// Profile What has been learnt of an opponent over many games.
+type Profile struct {
+	Name           string               `json:"name"`
+	Customizations b.Customizations     `json:"customizations"`
+	Games          int                  `json:"games"`
+	Outlasted      int                  `json:"outlasted"` // the games the opponent was still playing when we were eliminated
+	Observations   int                  `json:"observations"`
+	Matched        map[Tendency]float64 `json:"matched"`
+	Expected       map[Tendency]float64 `json:"expected"`
+	LatencyMS      int64                `json:"latencyMs"` // the total latency observed
+	LatencySamples int                  `json:"latencySamples"`
+	Updated        time.Time            `json:"updated"`
+}
+
+func newProfile(opponent b.Snake) *Profile {
+	return &Profile{
+		Name:           opponent.Name,
+		Customizations: opponent.Customizations,
+		Matched:        make(map[Tendency]float64),
+		Expected:       make(map[Tendency]float64),
+	}
+}
+
+// Strength Returns how often the opponent has outlasted us, from 0 to 1.
+func (p *Profile) Strength() float64 {
+	if p.Games == 0 {
+		return 0
+	}
+	return float64(p.Outlasted) / float64(p.Games)
+}
+
+// AverageLatencyMS Returns the average time the opponent takes to move.
+func (p *Profile) AverageLatencyMS() float64 {
+	if p.LatencySamples == 0 {
+		return 0
+	}
+	return float64(p.LatencyMS) / float64(p.LatencySamples)
+}
+
+// model Returns an opponent model that starts from what is known of the opponent.
+func (p *Profile) model(id string) *OpponentModel {
+	scale := 1.0
+	if p.Observations > maxPriorObservations {
+		scale = float64(maxPriorObservations) / float64(p.Observations)
+	}
+	model := newOpponentModel(id)
+	model.Name = p.Name
+	model.Customizations = p.Customizations
+	model.Observations = int(float64(p.Observations) * scale)
+	for tendency, matched := range p.Matched {
+		model.matched[tendency] = matched * scale
+	}
+	for tendency, expected := range p.Expected {
+		model.expected[tendency] = expected * scale
+	}
+	model.prior = &OpponentModel{
+		Observations: model.Observations,
+		matched:      copyCounts(model.matched),
+		expected:     copyCounts(model.expected),
+	}
+	return model
+}
+
+// learn Adds what was learnt of the opponent in one game.
+func (p *Profile) learn(model *OpponentModel, outlasted bool, now time.Time) {
+	prior := model.prior
+	if prior == nil {
+		prior = newOpponentModel(model.ID)
+	}
+	p.Games += 1
+	if outlasted {
+		p.Outlasted += 1
+	}
+	p.Observations += model.Observations - prior.Observations
+	for _, tendency := range tendencies {
+		p.Matched[tendency] += model.matched[tendency] - prior.matched[tendency]
+		p.Expected[tendency] += model.expected[tendency] - prior.expected[tendency]
+	}
+	p.LatencyMS += model.latencyMS
+	p.LatencySamples += model.latencySamples
+	p.Updated = now
+}
+
+func copyCounts(counts map[Tendency]float64) map[Tendency]float64 {
+	copied := make(map[Tendency]float64, len(counts))
+	for tendency, count := range counts {
+		copied[tendency] = count
+	}
+	return copied
+}
+
+// ProfileStore Persists the profile of each opponent as a JSON file. Opponents are told apart by
+// their name and customizations.
+type ProfileStore struct {
+	dir      string
+	mutex    sync.Mutex
+	profiles map[string]*Profile
+}
+
+func NewProfileStore(dir string) (*ProfileStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return &ProfileStore{
+		dir:      dir,
+		profiles: make(map[string]*Profile),
+	}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Path Returns the path to the file of an opponent's profile.
+func (p *ProfileStore) Path(opponent b.Snake) string {
+	hash := fnv.New32a()
+	custom := opponent.Customizations
+	_, _ = fmt.Fprintf(hash, "%s|%s|%s|%s", opponent.Name, custom.Color, custom.Head, custom.Tail)
+	name := unsafeFileChars.ReplaceAllString(opponent.Name, "_")
+	return filepath.Join(p.dir, fmt.Sprintf("%s-%08x.json", name, hash.Sum32()))
+}
+
+// Load Returns the profile of an opponent. An opponent that has not been seen before has an empty profile.
+func (p *ProfileStore) Load(opponent b.Snake) (*Profile, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	profile, err := p.load(opponent)
+	if err != nil {
+		return nil, err
+	}
+	copied := *profile
+	copied.Matched = copyCounts(profile.Matched)
+	copied.Expected = copyCounts(profile.Expected)
+	return &copied, nil
+}
+
+func (p *ProfileStore) load(opponent b.Snake) (*Profile, error) {
+	path := p.Path(opponent)
+	if profile, ok := p.profiles[path]; ok {
+		return profile, nil
+	}
+	profile := newProfile(opponent)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		p.profiles[path] = profile
+		return profile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile '%s': %w", path, err)
+	}
+	// A profile edited by hand may have no counts at all
+	if profile.Matched == nil {
+		profile.Matched = make(map[Tendency]float64)
+	}
+	if profile.Expected == nil {
+		profile.Expected = make(map[Tendency]float64)
+	}
+	p.profiles[path] = profile
+	return profile, nil
+}
+
+// learn Adds what was learnt of an opponent in one game to its profile and saves it.
+func (p *ProfileStore) learn(opponent b.Snake, model *OpponentModel, outlasted bool) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	profile, err := p.load(opponent)
+	if err != nil {
+		return err
+	}
+	profile.learn(model, outlasted, time.Now())
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so that a profile is never left half written
+	path := p.Path(opponent)
+	temp := path + ".tmp"
+	err = os.WriteFile(temp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
@@ -0,0 +1,128 @@
+package snacks
+
+import (
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ProfileStore_Path(t *testing.T) {
+	store, err := NewProfileStore(t.TempDir())
+	require.NoError(t, err)
+	snake := battlesnake.Snake{Name: "../Hungry Snake", Customizations: battlesnake.Customizations{Color: "#fff"}}
+	path := store.Path(snake)
+	require.Equal(t, store.dir, filepath.Dir(path))
+	require.Regexp(t, `^_Hungry_Snake-[0-9a-f]{8}\.json$`, filepath.Base(path))
+
+	// Snakes with the same name but different customizations are told apart
+	snake.Customizations.Color = "#000"
+	require.NotEqual(t, path, store.Path(snake))
+}
+
+func Test_ProfileStore_Load(t *testing.T) {
+	store, err := NewProfileStore(t.TempDir())
+	require.NoError(t, err)
+	profile, err := store.Load(battlesnake.Snake{Name: "Stranger"})
+	require.NoError(t, err)
+	require.Equal(t, "Stranger", profile.Name)
+	require.Equal(t, 0, profile.Games)
+	require.Equal(t, 0.0, profile.Strength())
+	require.Equal(t, 0.0, profile.AverageLatencyMS())
+
+	// A corrupt profile is reported
+	stranger := battlesnake.Snake{Name: "Corrupt"}
+	require.NoError(t, os.WriteFile(store.Path(stranger), []byte("{"), 0644))
+	_, err = store.Load(stranger)
+	require.Error(t, err)
+}
+
+func Test_ProfileStore_Learn_NullCounts(t *testing.T) {
+	store, err := NewProfileStore(t.TempDir())
+	require.NoError(t, err)
+	opponent := battlesnake.Snake{Name: "Edited"}
+	contents := `{"name": "Edited", "games": 2, "observations": 10, "matched": null, "expected": null}`
+	require.NoError(t, os.WriteFile(store.Path(opponent), []byte(contents), 0644))
+
+	model := newOpponentModel("a")
+	model.Observations = 1
+	model.matched[FoodSeeking] = 1
+	model.expected[FoodSeeking] = 1
+	require.NoError(t, store.learn(opponent, model, false))
+
+	profile, err := store.Load(opponent)
+	require.NoError(t, err)
+	require.Equal(t, 3, profile.Games)
+	require.Equal(t, 11, profile.Observations)
+	require.Equal(t, 1.0, profile.Matched[FoodSeeking])
+	require.Equal(t, 1.0, profile.Expected[FoodSeeking])
+}
+
+func Test_Profile_model(t *testing.T) {
+	profile := newProfile(battlesnake.Snake{Name: "Veteran"})
+	profile.Observations = maxPriorObservations * 4
+	profile.Matched[FoodSeeking] = float64(maxPriorObservations * 4)
+	profile.Expected[FoodSeeking] = float64(maxPriorObservations)
+
+	// A long history only counts for so much
+	model := profile.model("a")
+	require.Equal(t, maxPriorObservations, model.Observations)
+	require.Equal(t, float64(maxPriorObservations), model.matched[FoodSeeking])
+	require.Equal(t, FoodSeeking, model.Tendency())
+}
+
+func Test_StrategyDrivenSnake_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	play := func(gameID string, turns int) *StrategyDrivenSnake {
+		store, err := NewProfileStore(dir)
+		require.NoError(t, err)
+		snake := &StrategyDrivenSnake{strategies: []strategy{&StayInBounds{}, &NoCollisions{}}}
+		snake.SetProfiles(store)
+
+		start := chasingFood(0)
+		start.Game.ID = gameID
+		snake.Start(start)
+		for turn := 0; turn < turns; turn++ {
+			state := chasingFood(turn)
+			state.Game.ID = gameID
+			snake.Evaluate(state)
+		}
+
+		// We are eliminated, the opponent is not
+		end := chasingFood(turns)
+		end.Game.ID = gameID
+		end.Board.Snakes = end.Board.Snakes[1:]
+		snake.End(end)
+		return snake
+	}
+
+	play("game-1", 3)
+	store, err := NewProfileStore(dir)
+	require.NoError(t, err)
+	profile, err := store.Load(battlesnake.Snake{Name: "Hungry"})
+	require.NoError(t, err)
+	require.Equal(t, 1, profile.Games)
+	require.Equal(t, 1.0, profile.Strength())
+	require.Equal(t, 3, profile.Observations)
+	require.Equal(t, 50.0, profile.AverageLatencyMS())
+
+	// The opponent is known from the first turn of the next game
+	snake := &StrategyDrivenSnake{}
+	snake.SetProfiles(store)
+	next := chasingFood(0)
+	next.Game.ID = "game-2"
+	snake.Start(next)
+	model := opponentsOf(snake.memory().Get(next), next).Model("a")
+	require.Equal(t, FoodSeeking, model.Tendency())
+	require.Equal(t, 1.0, model.Predict(next, next.Board.Snakes[1])[battlesnake.UP])
+
+	// What was already known is not counted twice
+	play("game-3", 1)
+	store, err = NewProfileStore(dir)
+	require.NoError(t, err)
+	profile, err = store.Load(battlesnake.Snake{Name: "Hungry"})
+	require.NoError(t, err)
+	require.Equal(t, 2, profile.Games)
+	require.Equal(t, 4, profile.Observations)
+}
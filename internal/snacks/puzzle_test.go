@@ -0,0 +1,31 @@
+package snacks
+
+import (
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/puzzle"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var presets = []string{"DUMB", "HUNGRY", "SOLO", "BATTLE", "SQUAD", "CONSTRICTOR"}
+
+// Test_Puzzles Checks every preset snake's answer to each puzzle in testdata/puzzles.
+func Test_Puzzles(t *testing.T) {
+	puzzles, err := puzzle.LoadDir("testdata/puzzles")
+	require.NoError(t, err)
+	require.NotEmpty(t, puzzles)
+	for _, p := range puzzles {
+		for _, name := range presets {
+			if !p.AppliesTo(name) {
+				continue
+			}
+			p, name := p, name
+			t.Run(p.Name+"/"+name, func(t *testing.T) {
+				snake, err := NewSnake(name, nil)
+				require.NoError(t, err)
+				move := snake.Move(p.State).Move
+				require.NoError(t, p.Check(move), "%s\n%s", p.Description, battlesnake.Render(p.State))
+			})
+		}
+	}
+}
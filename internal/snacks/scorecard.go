@@ -3,9 +3,14 @@ package snacks
 import (
 	"fmt"
 	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
-	"math"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
+	"sort"
+	"strings"
 )
 
+// moveOrder The order in which moves that tie for the same score are preferred.
+var moveOrder = []b.Move{b.UP, b.DOWN, b.LEFT, b.RIGHT}
+
 type Score int
 
 func (s Score) String() string {
@@ -15,7 +20,8 @@ func (s Score) String() string {
 type Scorecard struct {
 	state       b.GameState
 	moves       map[b.Move]Score
-	defaultMove b.Move // the default move is played if there are no safe moves
+	defaultMove b.Move                   // the default move is played if there are no safe moves
+	strategies  []*b.StrategyExplanation // what each strategy thought of each move, in the order they ran
 }
 
 func NewScorecard(state b.GameState) *Scorecard {
@@ -28,6 +34,7 @@ func NewScorecard(state b.GameState) *Scorecard {
 			b.DOWN:  0,
 		},
 		defaultMove: b.DOWN,
+		strategies:  make([]*b.StrategyExplanation, 0),
 	}
 }
 
@@ -45,23 +52,32 @@ func (s *Scorecard) Unsafe(move b.Move) {
 	delete(s.moves, move)
 }
 
-// Best Returns the move with the best score.
+// Best Returns the move with the best score. Moves that tie for the best score are
+// preferred in the order up, down, left then right.
 func (s *Scorecard) Best() b.Move {
-	if len(s.moves) == 0 {
+	ranking := s.ranking()
+	if len(ranking) == 0 {
 		logger(s.state).Msg("No safe moves!")
+		metrics.NoSafeMoves.Inc()
 		return s.defaultMove
 	}
+	bestMove := ranking[0].Move
+	debug(s.state).Msgf("Chose %s as best from %v", bestMove, s.moves)
+	return bestMove
+}
 
-	bestScore := Score(math.MinInt)
-	var bestMove b.Move
-	for move, score := range s.moves {
-		if score > bestScore {
-			bestScore = score
-			bestMove = move
+// ranking Returns the safe moves from best to worst.
+func (s *Scorecard) ranking() []b.RankedMove {
+	ranking := make([]b.RankedMove, 0, len(s.moves))
+	for _, move := range moveOrder {
+		if score, ok := s.moves[move]; ok {
+			ranking = append(ranking, b.RankedMove{Move: move, Score: int(score)})
 		}
 	}
-	logger(s.state).Msgf("Chose %s as best from %v", bestMove, s.moves)
-	return bestMove
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Score > ranking[j].Score
+	})
+	return ranking
 }
 
 func (s *Scorecard) SafeMoves() []b.Move {
@@ -80,6 +96,86 @@ func (s *Scorecard) Scores() map[b.Move]Score {
 	return moves
 }
 
+// scoresByMove Returns the score of each safe move.
+func (s *Scorecard) scoresByMove() map[b.Move]int {
+	scores := make(map[b.Move]int)
+	for k, v := range s.moves {
+		scores[k] = int(v)
+	}
+	return scores
+}
+
+// strategy Returns the explanation of a strategy, creating it if the strategy has not yet been seen.
+func (s *Scorecard) strategy(label string) *b.StrategyExplanation {
+	for _, strategy := range s.strategies {
+		if strategy.Name == label {
+			return strategy
+		}
+	}
+	strategy := &b.StrategyExplanation{Name: label}
+	s.strategies = append(s.strategies, strategy)
+	return strategy
+}
+
+// contribute Records the score that a strategy added to a move.
+func (s *Scorecard) contribute(label string, move b.Move, toAdd Score) {
+	strategy := s.strategy(label)
+	if _, ok := s.moves[move]; !ok {
+		return // The move was already marked unsafe
+	}
+	if strategy.Contributions == nil {
+		strategy.Contributions = make(map[b.Move]int)
+	}
+	strategy.Contributions[move] += int(toAdd)
+}
+
+// veto Records that a strategy marked a move as unsafe.
+func (s *Scorecard) veto(label string, move b.Move) {
+	strategy := s.strategy(label)
+	for _, vetoed := range strategy.Vetoes {
+		if vetoed == move {
+			return
+		}
+	}
+	strategy.Vetoes = append(strategy.Vetoes, move)
+}
+
+// reason Records why a strategy scored the moves the way it did.
+func (s *Scorecard) reason(label string, reason string) {
+	strategy := s.strategy(label)
+	strategy.Reasons = append(strategy.Reasons, reason)
+}
+
+// Explain Returns how the best move was chosen.
+func (s *Scorecard) Explain() b.Explanation {
+	explanation := b.Explanation{
+		Strategies: make([]b.StrategyExplanation, 0, len(s.strategies)),
+		Ranking:    s.ranking(),
+		Chosen:     s.defaultMove,
+	}
+	for _, strategy := range s.strategies {
+		explanation.Strategies = append(explanation.Strategies, *strategy)
+	}
+	if len(explanation.Ranking) == 0 {
+		explanation.TieBreak = fmt.Sprintf("no safe moves, chose the default %s", s.defaultMove)
+		return explanation
+	}
+
+	best := explanation.Ranking[0]
+	explanation.Chosen = best.Move
+	tied := make([]string, 0)
+	for _, ranked := range explanation.Ranking {
+		if ranked.Score == best.Score {
+			tied = append(tied, ranked.Move.String())
+		}
+	}
+	if len(tied) > 1 {
+		explanation.TieBreak = fmt.Sprintf("%s tied at %d, chose %s by move order",
+			strings.Join(tied, " "), best.Score, best.Move)
+	}
+	return explanation
+}
+
 type LoggingScorecard struct {
 	label     string      // the label prefixed to all logging
 	state     b.GameState // the game state
@@ -98,15 +194,24 @@ func (s *LoggingScorecard) Add(move b.Move, toAdd Score) Score {
 	if toAdd != Score(0) {
 		debug(s.state).Msgf("%s: %s +%d", s.label, move, toAdd)
 	}
+	s.scorecard.contribute(s.label, move, toAdd)
 	return s.scorecard.Add(move, toAdd)
 }
 
 // Unsafe Marks a move as unsafe.
 func (s *LoggingScorecard) Unsafe(move b.Move) {
 	debug(s.state).Msgf("%s: %s 🚫", s.label, move)
+	s.scorecard.veto(s.label, move)
 	s.scorecard.Unsafe(move)
 }
 
+// Reason Records why the strategy scored the moves the way it did.
+func (s *LoggingScorecard) Reason(format string, args ...interface{}) {
+	reason := fmt.Sprintf(format, args...)
+	debug(s.state).Msgf("%s: %s", s.label, reason)
+	s.scorecard.reason(s.label, reason)
+}
+
 // Best Returns the move with the best score.
 func (s *LoggingScorecard) Best() b.Move {
 	return s.scorecard.Best()
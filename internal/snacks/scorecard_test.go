@@ -2,6 +2,7 @@ package snacks
 
 import (
 	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -60,3 +61,82 @@ func Test_Scorecard_Scores(t *testing.T) {
 	require.Equal(t, Score(3), safeMoves[battlesnake.UP])
 	require.Equal(t, Score(4), safeMoves[battlesnake.DOWN])
 }
+
+func Test_Scorecard_Explain_Scores(t *testing.T) {
+	s := NewScorecard(state())
+	NewLoggingScorecard("first", state(), s).Add(battlesnake.LEFT, 10)
+	NewLoggingScorecard("second", state(), s).Add(battlesnake.LEFT, 5)
+	NewLoggingScorecard("second", state(), s).Add(battlesnake.RIGHT, 3)
+	NewLoggingScorecard("second", state(), s).Unsafe(battlesnake.UP)
+	NewLoggingScorecard("third", state(), s).Add(battlesnake.UP, 1)
+	explanation := s.Explain()
+	require.Equal(t, map[string]map[battlesnake.Move]int{
+		"first":  {battlesnake.LEFT: 10},
+		"second": {battlesnake.LEFT: 5, battlesnake.RIGHT: 3},
+	}, explanation.Scores())
+}
+
+func Test_Scorecard_BestBreaksTies(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		s := NewScorecard(state())
+		s.Add(battlesnake.RIGHT, 5)
+		s.Add(battlesnake.LEFT, 5)
+		s.Add(battlesnake.DOWN, 5)
+		require.Equal(t, battlesnake.DOWN, s.Best())
+	}
+}
+
+func Test_Scorecard_Explain(t *testing.T) {
+	s := NewScorecard(state())
+	bounds := NewLoggingScorecard("bounds", state(), s)
+	bounds.Unsafe(battlesnake.LEFT)
+	bounds.Unsafe(battlesnake.DOWN)
+	food := NewLoggingScorecard("food", state(), s)
+	food.Reason("Food is at %s", battlesnake.Coord{3, 3})
+	food.Add(battlesnake.RIGHT, 4)
+	food.Add(battlesnake.UP, 4)
+	food.Add(battlesnake.LEFT, 4)
+
+	explanation := s.Explain()
+	require.Equal(t, []battlesnake.StrategyExplanation{
+		{
+			Name:   "bounds",
+			Vetoes: []battlesnake.Move{battlesnake.LEFT, battlesnake.DOWN},
+		},
+		{
+			Name:          "food",
+			Contributions: map[battlesnake.Move]int{battlesnake.RIGHT: 4, battlesnake.UP: 4},
+			Reasons:       []string{"Food is at (3,3)"},
+		},
+	}, explanation.Strategies)
+	require.Equal(t, []battlesnake.RankedMove{
+		{Move: battlesnake.UP, Score: 4},
+		{Move: battlesnake.RIGHT, Score: 4},
+	}, explanation.Ranking)
+	require.Equal(t, battlesnake.UP, explanation.Chosen)
+	require.Equal(t, "⭡ ⭢ tied at 4, chose ⭡ by move order", explanation.TieBreak)
+}
+
+func Test_Scorecard_ExplainNoSafeMoves(t *testing.T) {
+	s := NewScorecard(state())
+	for _, move := range []battlesnake.Move{battlesnake.UP, battlesnake.DOWN, battlesnake.LEFT, battlesnake.RIGHT} {
+		s.Unsafe(move)
+	}
+	explanation := s.Explain()
+	require.Empty(t, explanation.Ranking)
+	require.Equal(t, battlesnake.DOWN, explanation.Chosen)
+	require.Contains(t, explanation.TieBreak, "no safe moves")
+}
+
+func Test_Scorecard_BestCountsNoSafeMoves(t *testing.T) {
+	s := NewScorecard(state())
+	before := metrics.NoSafeMoves.Value()
+	s.Best()
+	require.Equal(t, before, metrics.NoSafeMoves.Value())
+
+	for _, move := range []battlesnake.Move{battlesnake.UP, battlesnake.DOWN, battlesnake.LEFT, battlesnake.RIGHT} {
+		s.Unsafe(move)
+	}
+	require.Equal(t, battlesnake.DOWN, s.Best())
+	require.Equal(t, before+1, metrics.NoSafeMoves.Value())
+}
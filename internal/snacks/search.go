@@ -0,0 +1,452 @@
+package snacks
+
+import (
+	"context"
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSearchRollouts = 64 // the number of games played out from each move
+	DefaultSearchDepth    = 8  // the number of turns each game is played out for
+)
+
+// SearchConfig How a snake searches ahead for the moves it survives longest after.
+type SearchConfig struct {
+	Workers  int           // the number of goroutines playing games out at once; zero for one per CPU
+	Budget   time.Duration // the longest to search each move; zero for a quarter of the game's timeout
+	Rollouts int           // the number of games played out from each move; zero for the default
+	Depth    int           // the number of turns each game is played out for; zero for the default
+
+	// Deterministic Searches on a single goroutine and ignores the deadline, so the same state is
+	// always scored the same. Meant for tests and replays.
+	Deterministic bool
+}
+
+// workers Returns the number of goroutines to search with.
+func (c SearchConfig) workers() int {
+	if c.Deterministic {
+		return 1
+	}
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// deadline Returns when the search must stop, if there is a limit.
+func (c SearchConfig) deadline(state b.GameState, started time.Time) (time.Time, bool) {
+	if c.Deterministic {
+		return time.Time{}, false
+	}
+	budget := c.Budget
+	if budget <= 0 {
+		budget = time.Duration(state.Game.Timeout) * time.Millisecond / 4
+	}
+	if budget <= 0 {
+		return time.Time{}, false
+	}
+	return started.Add(budget), true
+}
+
+func (c SearchConfig) rollouts() int {
+	if c.Rollouts > 0 {
+		return c.Rollouts
+	}
+	return DefaultSearchRollouts
+}
+
+func (c SearchConfig) depth() int {
+	if c.Depth > 0 {
+		return c.Depth
+	}
+	return DefaultSearchDepth
+}
+
+// SearchResult How a move fared when the game was played out after it.
+type SearchResult struct {
+	Rollouts int     // the number of games played out before the deadline
+	Survival float64 // the average fraction of the turns played out that the snake survived
+	turns    int     // the number of turns survived across every game
+}
+
+// Search Plays the game out after each move, many times over, to find how long the snake survives
+// after each. Your snake moves at random and each opponent moves as its model predicts, or at random
+// if nothing has been learnt of it. The games are shared among the workers until every game is
+// played or the deadline passes. Each game is seeded by its turn and index alone, so every move is
+// played out against the same opponents' moves and, unless the deadline cuts the search short, the
+// results do not depend on the number of workers.
+func Search(state b.GameState, moves []b.Move, opponents *Opponents, config SearchConfig) map[b.Move]SearchResult {
+	ctx := context.Background()
+	if deadline, ok := config.deadline(state, time.Now()); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	// The models are looked up once, as they are shared by every game
+	models := make(map[string]*OpponentModel)
+	for _, snake := range state.Board.Snakes {
+		if snake.ID == state.You.ID {
+			continue
+		}
+		if model := opponents.Model(snake.ID); model.Observations > 0 {
+			models[snake.ID] = model
+		}
+	}
+
+	// Each worker keeps its own tally of the games it played, counted in whole turns
+	depth := config.depth()
+	tallies := make([]map[b.Move]SearchResult, config.workers())
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := range tallies {
+		tally := make(map[b.Move]SearchResult)
+		tallies[w] = tally
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				// Interleave the moves so that a deadline cuts each move's search short evenly
+				move, index := moves[i%len(moves)], i/len(moves)
+				turns, ok := playOut(ctx, state, models, move, index, depth)
+				if ok {
+					result := tally[move]
+					result.Rollouts += 1
+					result.turns += turns
+					tally[move] = result
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < len(moves)*config.rollouts(); i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	results := make(map[b.Move]SearchResult)
+	for _, move := range moves {
+		result := SearchResult{}
+		for _, tally := range tallies {
+			result.Rollouts += tally[move].Rollouts
+			result.turns += tally[move].turns
+		}
+		if result.Rollouts > 0 {
+			result.Survival = float64(result.turns) / float64(result.Rollouts*depth)
+		}
+		results[move] = result
+	}
+	return results
+}
+
+// playOut Plays a game out after a move and returns the number of turns that the snake survived,
+// or false if the deadline passed first. Outliving every opponent counts as surviving every turn.
+func playOut(ctx context.Context, state b.GameState, models map[string]*OpponentModel, move b.Move, index int, depth int) (int, bool) {
+	random := rand.New(rand.NewSource(int64(state.Turn)<<32 | int64(index)))
+	sim := newSimulation(state)
+	moves := make([]b.Move, len(sim.snakes))
+	for turn := 0; turn < depth; turn++ {
+		if ctx.Err() != nil {
+			return 0, false
+		}
+		occupied := sim.occupied()
+		var current b.GameState
+		var snakes []b.Snake
+		if len(models) > 0 {
+			current, snakes = sim.gameState(state)
+		}
+		for i, snake := range sim.snakes {
+			moves[i] = sim.randomMove(random, occupied, i)
+			if model, ok := models[snake.id]; ok && snake.alive {
+				if predicted, ok := sample(random, model.Predict(current, snakes[i])); ok {
+					moves[i] = predicted
+				}
+			}
+		}
+		if turn == 0 && sim.you >= 0 {
+			moves[sim.you] = move
+		}
+		sim.step(moves)
+		if !sim.alive(sim.you) {
+			return turn, true
+		}
+		if len(sim.snakes) > 1 && sim.survivors() == 1 {
+			break
+		}
+	}
+	return depth, true
+}
+
+// simulatedSnake A snake as it moves through a simulated game.
+type simulatedSnake struct {
+	id     string
+	body   []b.Coord
+	health int
+	alive  bool
+}
+
+// simulation A copy of a game that can be played forward without changing the game. It follows
+// the rules closely enough to tell which snakes survive, but no new food is placed.
+type simulation struct {
+	grid         b.Grid
+	constrictor  bool
+	hazardDamage int
+	hazards      map[b.Coord]bool
+	food         map[b.Coord]bool
+	snakes       []simulatedSnake
+	you          int // the index of our snake, or -1 if it is not on the board
+}
+
+func newSimulation(state b.GameState) *simulation {
+	sim := &simulation{
+		grid:         b.NewGrid(state),
+		constrictor:  state.Game.Ruleset.IsConstrictor(),
+		hazardDamage: state.Game.Ruleset.Settings.HazardDamagePerTurn,
+		hazards:      make(map[b.Coord]bool),
+		food:         make(map[b.Coord]bool),
+		snakes:       make([]simulatedSnake, 0, len(state.Board.Snakes)),
+		you:          -1,
+	}
+	for _, hazard := range state.Board.Hazards {
+		sim.hazards[hazard] = true
+	}
+	for _, food := range state.Board.Food {
+		sim.food[food] = true
+	}
+	for _, snake := range state.Board.Snakes {
+		if len(snake.Body) == 0 {
+			continue
+		}
+		if snake.ID == state.You.ID {
+			sim.you = len(sim.snakes)
+		}
+		sim.snakes = append(sim.snakes, simulatedSnake{
+			id:     snake.ID,
+			body:   append([]b.Coord(nil), snake.Body...),
+			health: snake.Health,
+			alive:  true,
+		})
+	}
+	return sim
+}
+
+// occupied Returns the squares that no snake can move into next turn. A tail moves out of the way
+// unless the snake just ate.
+func (s *simulation) occupied() map[b.Coord]bool {
+	occupied := make(map[b.Coord]bool)
+	for _, snake := range s.snakes {
+		if !snake.alive {
+			continue
+		}
+		last := len(snake.body) - 1
+		for i, part := range snake.body {
+			if i == last && i > 0 && part != snake.body[i-1] {
+				continue
+			}
+			occupied[part] = true
+		}
+	}
+	return occupied
+}
+
+// randomMove Returns any move that keeps a snake on the board and out of the bodies of snakes.
+func (s *simulation) randomMove(random *rand.Rand, occupied map[b.Coord]bool, i int) b.Move {
+	snake := s.snakes[i]
+	if !snake.alive {
+		return b.UP
+	}
+	candidates := make([]b.Move, 0, len(allMoves))
+	for _, move := range allMoves {
+		next := s.grid.Move(snake.body[0], move)
+		if s.grid.InBounds(next) && !occupied[next] {
+			candidates = append(candidates, move)
+		}
+	}
+	if len(candidates) == 0 {
+		return allMoves[random.Intn(len(allMoves))]
+	}
+	return candidates[random.Intn(len(candidates))]
+}
+
+// gameState Returns the simulated game as a game state, along with each simulated snake as it
+// appears on the board, so that opponent models can predict from it.
+func (s *simulation) gameState(template b.GameState) (b.GameState, []b.Snake) {
+	state := template
+	state.Board.Food = make([]b.Coord, 0, len(s.food))
+	for food := range s.food {
+		state.Board.Food = append(state.Board.Food, food)
+	}
+	sort.Slice(state.Board.Food, func(i, j int) bool {
+		return state.Board.Food[i].X < state.Board.Food[j].X ||
+			state.Board.Food[i].X == state.Board.Food[j].X && state.Board.Food[i].Y < state.Board.Food[j].Y
+	})
+	state.Board.Snakes = make([]b.Snake, 0, len(s.snakes))
+	snakes := make([]b.Snake, len(s.snakes))
+	for i, snake := range s.snakes {
+		snakes[i] = b.Snake{
+			ID:     snake.id,
+			Health: snake.health,
+			Body:   snake.body,
+			Head:   snake.body[0],
+			Length: len(snake.body),
+		}
+		if snake.alive {
+			state.Board.Snakes = append(state.Board.Snakes, snakes[i])
+		}
+	}
+	return state, snakes
+}
+
+// sample Returns a move chosen at random with the predicted chance of each, or false if no move
+// is predicted.
+func sample(random *rand.Rand, predictions map[b.Move]float64) (b.Move, bool) {
+	total := 0.0
+	for _, move := range allMoves {
+		total += predictions[move]
+	}
+	if total <= 0 {
+		return "", false
+	}
+	chance := random.Float64() * total
+	var chosen b.Move
+	for _, move := range allMoves {
+		if predictions[move] <= 0 {
+			continue
+		}
+		chosen = move
+		if chance < predictions[move] {
+			break
+		}
+		chance -= predictions[move]
+	}
+	return chosen, true
+}
+
+// step Plays one turn in which each snake makes the given move.
+func (s *simulation) step(moves []b.Move) {
+	// Move each snake and take away the health it spends
+	for i := range s.snakes {
+		snake := &s.snakes[i]
+		if !snake.alive {
+			continue
+		}
+		copy(snake.body[1:], snake.body[:len(snake.body)-1])
+		snake.body[0] = s.grid.Move(snake.body[0], moves[i])
+		snake.health -= 1
+		if s.hazards[snake.body[0]] {
+			snake.health -= s.hazardDamage
+		}
+	}
+
+	// Feed the snakes; more than one snake can eat the same food
+	eaten := make([]b.Coord, 0)
+	for i := range s.snakes {
+		snake := &s.snakes[i]
+		if !snake.alive {
+			continue
+		}
+		if s.constrictor || s.food[snake.body[0]] {
+			snake.health = 100
+			snake.body = append(snake.body, snake.body[len(snake.body)-1])
+			eaten = append(eaten, snake.body[0])
+		}
+	}
+	for _, food := range eaten {
+		delete(s.food, food)
+	}
+
+	// Decide who is eliminated before removing anyone, as in the rules
+	eliminated := make([]bool, len(s.snakes))
+	for i, snake := range s.snakes {
+		if !snake.alive {
+			continue
+		}
+		head := snake.body[0]
+		eliminated[i] = snake.health <= 0 || !s.grid.InBounds(head)
+		for j, other := range s.snakes {
+			if !other.alive || eliminated[i] {
+				continue
+			}
+			for _, part := range other.body[1:] {
+				if part == head {
+					eliminated[i] = true
+				}
+			}
+			if i != j && other.body[0] == head && len(other.body) >= len(snake.body) {
+				eliminated[i] = true
+			}
+		}
+	}
+	for i := range s.snakes {
+		if eliminated[i] {
+			s.snakes[i].alive = false
+		}
+	}
+}
+
+// alive Returns true if the snake at an index is still in the game.
+func (s *simulation) alive(i int) bool {
+	return i >= 0 && s.snakes[i].alive
+}
+
+// survivors Returns the number of snakes still in the game.
+func (s *simulation) survivors() int {
+	count := 0
+	for _, snake := range s.snakes {
+		if snake.alive {
+			count += 1
+		}
+	}
+	return count
+}
+
+// SearchAhead allows a snake to prefer the moves that it survives longest after, as found by
+// playing the game out many times from each safe move with every opponent moving as predicted from
+// how it has moved so far in the game. Without that history, opponents move at random.
+type SearchAhead struct {
+	weight float64
+	config SearchConfig
+}
+
+func (s *SearchAhead) move(state b.GameState, card *Scorecard) {
+	s.search(state, newOpponents(), card)
+}
+
+func (s *SearchAhead) moveWithSession(state b.GameState, session *Session, card *Scorecard) {
+	s.search(state, opponentsOf(session, state), card)
+}
+
+func (s *SearchAhead) search(state b.GameState, opponents *Opponents, card *Scorecard) {
+	scorecard := NewLoggingScorecard("search-ahead", state, card)
+	safe := make(map[b.Move]bool)
+	for _, move := range card.SafeMoves() {
+		safe[move] = true
+	}
+	moves := make([]b.Move, 0, len(safe))
+	for _, move := range moveOrder {
+		if safe[move] {
+			moves = append(moves, move)
+		}
+	}
+	if len(moves) < 2 {
+		return // There is nothing to choose between
+	}
+
+	results := Search(state, moves, opponents, s.config)
+	for _, move := range moves {
+		result := results[move]
+		scorecard.Reason("Survived %.0f%% of %d turn(s) after %s in %d game(s)",
+			100*result.Survival, s.config.depth(), move, result.Rollouts)
+		scorecard.Add(move, Score(s.weight*result.Survival))
+	}
+}
@@ -0,0 +1,266 @@
+package snacks
+
+import (
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// simulated Returns a simulation of a board along with the index of each snake by ID.
+func simulated(state battlesnake.GameState) (*simulation, map[string]int) {
+	sim := newSimulation(state)
+	index := make(map[string]int)
+	for i, snake := range sim.snakes {
+		index[snake.id] = i
+	}
+	return sim, index
+}
+
+func Test_simulation_HeadToHead(t *testing.T) {
+	sim, index := simulated(battlesnake.MustParseBoard(`
+		. . . . .
+		. H . A .
+		. 1 . a a
+	`))
+	moves := make([]battlesnake.Move, 2)
+	moves[index["you"]] = battlesnake.RIGHT
+	moves[index["a"]] = battlesnake.LEFT
+	sim.step(moves)
+	require.False(t, sim.alive(index["you"]), "the smaller snake loses")
+	require.True(t, sim.alive(index["a"]))
+	require.Equal(t, 1, sim.survivors())
+}
+
+func Test_simulation_Eliminations(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . . . .
+		. H . . .
+		. 1 2 . .
+	`)
+	tests := []struct {
+		name   string
+		move   battlesnake.Move
+		health int
+		alive  bool
+	}{
+		{name: "moves", move: battlesnake.RIGHT, health: 100, alive: true},
+		{name: "moves to the edge", move: battlesnake.LEFT, health: 100, alive: true},
+		{name: "hits its own body", move: battlesnake.DOWN, health: 100, alive: false},
+		{name: "starves", move: battlesnake.RIGHT, health: 1, alive: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state.Board.Snakes[0].Health = test.health
+			sim := newSimulation(state)
+			sim.step([]battlesnake.Move{test.move})
+			require.Equal(t, test.alive, sim.alive(0))
+		})
+	}
+
+	// Leaving the board from its edge
+	sim := newSimulation(state)
+	sim.step([]battlesnake.Move{battlesnake.LEFT})
+	sim.step([]battlesnake.Move{battlesnake.LEFT})
+	require.False(t, sim.alive(0))
+}
+
+func Test_simulation_Food(t *testing.T) {
+	sim := newSimulation(battlesnake.MustParseBoard(`
+		. . . . .
+		. H * . .
+		. 1 2 . .
+	`))
+	sim.snakes[0].health = 50
+	sim.step([]battlesnake.Move{battlesnake.RIGHT})
+	require.True(t, sim.alive(0), "the tail moved out of the way")
+	require.Equal(t, 100, sim.snakes[0].health)
+	require.Len(t, sim.snakes[0].body, 4)
+	require.Empty(t, sim.food)
+
+	// The stacked tail does not move out of the way on the turn after eating
+	occupied := sim.occupied()
+	require.True(t, occupied[battlesnake.Coord{X: 1, Y: 1}])
+}
+
+func Test_simulation_Wrapped(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . . . .
+		H 1 2 . .
+		. . . . .
+	`)
+	state.Game.Ruleset.Name = battlesnake.RulesetWrapped
+	sim := newSimulation(state)
+	sim.step([]battlesnake.Move{battlesnake.LEFT})
+	require.True(t, sim.alive(0), "the snake wraps around to the other side")
+	require.Equal(t, battlesnake.Coord{X: 4, Y: 1}, sim.snakes[0].body[0])
+	sim.step([]battlesnake.Move{battlesnake.LEFT})
+	require.True(t, sim.alive(0))
+}
+
+func Test_simulation_HazardDamage(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . # . .
+		. H # . .
+		. 1 2 . .
+	`)
+	state.Board.Snakes[0].Health = 20
+	sim := newSimulation(state)
+	sim.step([]battlesnake.Move{battlesnake.RIGHT})
+	require.True(t, sim.alive(0), "a hazard does no damage unless the rules say so")
+	require.Equal(t, 19, sim.snakes[0].health)
+
+	state.Game.Ruleset.Settings.HazardDamagePerTurn = 14
+	sim = newSimulation(state)
+	sim.step([]battlesnake.Move{battlesnake.RIGHT})
+	require.True(t, sim.alive(0))
+	require.Equal(t, 5, sim.snakes[0].health)
+	sim.step([]battlesnake.Move{battlesnake.UP})
+	require.False(t, sim.alive(0), "starved by the hazard")
+
+	// The search steers clear of a hazard that would starve the snake
+	results := Search(state, []battlesnake.Move{battlesnake.UP, battlesnake.RIGHT}, newOpponents(), SearchConfig{Deterministic: true})
+	require.Less(t, results[battlesnake.RIGHT].Survival, results[battlesnake.UP].Survival)
+}
+
+func Test_simulation_DoesNotChangeTheGame(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . . . .
+		. H * . .
+		. 1 2 . .
+	`)
+	sim := newSimulation(state)
+	sim.step([]battlesnake.Move{battlesnake.RIGHT})
+	require.Equal(t, battlesnake.Coord{X: 1, Y: 1}, state.Board.Snakes[0].Body[0])
+	require.Len(t, state.Board.Snakes[0].Body, 3)
+	require.Len(t, state.Board.Food, 1)
+}
+
+// pocket Your snake can turn left into a pocket it has wrapped itself around, too far from its tail
+// to escape, or right into open space.
+func pocket() battlesnake.GameState {
+	return battlesnake.MustParseBoard(`
+		. . . . . . .
+		3 2 1 . . . .
+		4 . H . . . .
+		5 6 7 8 9 . .
+		. . . . . . .
+	`)
+}
+
+func Test_Search(t *testing.T) {
+	moves := []battlesnake.Move{battlesnake.LEFT, battlesnake.RIGHT}
+	results := Search(pocket(), moves, newOpponents(), SearchConfig{Deterministic: true})
+	require.Equal(t, DefaultSearchRollouts, results[battlesnake.LEFT].Rollouts)
+	require.Equal(t, DefaultSearchRollouts, results[battlesnake.RIGHT].Rollouts)
+	require.Equal(t, 1.0/DefaultSearchDepth, results[battlesnake.LEFT].Survival, "trapped after one turn")
+	require.Greater(t, results[battlesnake.RIGHT].Survival, 0.9)
+}
+
+// meetingFood A game where you are smaller than an opponent that has been seen heading for the food
+// that you are both next to.
+func meetingFood() battlesnake.GameState {
+	return chasingFoodOn(3, `
+		. 1 H * . . .
+		. . . A . . .
+		. . . a . . .
+		. . . a . . .
+		. . . . . . .
+		. . . . . . .
+		. . . . . . .
+	`)
+}
+
+func Test_Search_Predicted(t *testing.T) {
+	state := meetingFood()
+	moves := []battlesnake.Move{battlesnake.DOWN, battlesnake.RIGHT}
+
+	// At random, the opponent only sometimes moves to the food
+	config := SearchConfig{Deterministic: true}
+	results := Search(state, moves, newOpponents(), config)
+	require.Greater(t, results[battlesnake.RIGHT].Survival, 0.0)
+
+	// Having seen it chase food, the opponent always does
+	opponents := newOpponents()
+	for turn := 1; turn <= 3; turn++ {
+		opponents.observe(chasingFood(turn-1), chasingFood(turn))
+	}
+	results = Search(state, moves, opponents, config)
+	require.Equal(t, 0.0, results[battlesnake.RIGHT].Survival, "loses the head-to-head over the food")
+	require.Greater(t, results[battlesnake.DOWN].Survival, 0.5)
+}
+
+func Test_sample(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	counts := make(map[battlesnake.Move]int)
+	for i := 0; i < 1000; i++ {
+		move, ok := sample(random, map[battlesnake.Move]float64{battlesnake.UP: 0.75, battlesnake.LEFT: 0.25, battlesnake.DOWN: 0})
+		require.True(t, ok)
+		counts[move] += 1
+	}
+	require.InDelta(t, 750, counts[battlesnake.UP], 50)
+	require.InDelta(t, 250, counts[battlesnake.LEFT], 50)
+	require.Zero(t, counts[battlesnake.DOWN])
+
+	_, ok := sample(random, map[battlesnake.Move]float64{})
+	require.False(t, ok)
+}
+
+func Test_Search_Deterministic(t *testing.T) {
+	state := chasingFood(1)
+	moves := []battlesnake.Move{battlesnake.UP, battlesnake.RIGHT}
+	expected := Search(state, moves, newOpponents(), SearchConfig{Deterministic: true, Rollouts: 200})
+	require.Equal(t, expected, Search(state, moves, newOpponents(), SearchConfig{Deterministic: true, Rollouts: 200}))
+
+	// Without a deadline, the number of workers does not change the results
+	require.Equal(t, expected, Search(state, moves, newOpponents(), SearchConfig{Workers: 8, Rollouts: 200}))
+}
+
+func Test_Search_Deadline(t *testing.T) {
+	state := pocket()
+	state.Game.Timeout = 500
+	moves := []battlesnake.Move{battlesnake.LEFT, battlesnake.RIGHT, battlesnake.DOWN}
+
+	// The workers share one deadline
+	started := time.Now()
+	results := Search(state, moves, newOpponents(), SearchConfig{Workers: 4, Budget: 20 * time.Millisecond, Rollouts: 1 << 30})
+	require.Less(t, time.Since(started), 500*time.Millisecond)
+	for _, move := range moves {
+		require.Greater(t, results[move].Rollouts, 0, move)
+		require.Less(t, results[move].Rollouts, 1<<30, move)
+	}
+
+	// Without a budget, the search may take a quarter of the game's timeout
+	deadline, ok := SearchConfig{}.deadline(state, started)
+	require.True(t, ok)
+	require.Equal(t, started.Add(125*time.Millisecond), deadline)
+
+	// A deterministic search has no deadline
+	_, ok = SearchConfig{Deterministic: true, Budget: time.Millisecond}.deadline(state, started)
+	require.False(t, ok)
+}
+
+func Test_SearchAhead(t *testing.T) {
+	snake := BattleSnake()
+	snake.SetSearch(SearchConfig{Deterministic: true})
+	evaluation := snake.Evaluate(pocket())
+	scores := evaluation.Scores["search-ahead"]
+	require.Greater(t, scores[battlesnake.RIGHT], scores[battlesnake.LEFT])
+	require.NotContains(t, scores, battlesnake.UP, "unsafe moves are not searched")
+}
+
+func Test_SearchAhead_Session(t *testing.T) {
+	// The opponents are predicted from how they have moved so far in the game
+	snake := &StrategyDrivenSnake{strategies: []strategy{
+		&StayInBounds{},
+		&NoCollisions{},
+		&SearchAhead{weight: 40, config: SearchConfig{Deterministic: true}},
+	}}
+	for turn := 0; turn < 3; turn++ {
+		snake.Evaluate(chasingFood(turn))
+	}
+	evaluation := snake.Evaluate(meetingFood())
+	require.Equal(t, 0, evaluation.Scores["search-ahead"][battlesnake.RIGHT])
+	require.Equal(t, battlesnake.DOWN, evaluation.Response.Move)
+}
@@ -0,0 +1,153 @@
+package snacks
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"sync"
+	"time"
+)
+
+// SessionTTL How long a session is kept after its game was last seen. Games that end without
+// an /end request, for example when the engine restarts, are forgotten after this.
+const SessionTTL = 10 * time.Minute
+
+// maxHistory The number of previous turns each session remembers.
+const maxHistory = 64
+
+// Session What a snake remembers between the turns of one game.
+type Session struct {
+	GameID   string
+	SnakeID  string
+	mutex    sync.Mutex
+	history  []b.GameState // the previous turns, oldest first
+	values   map[string]interface{}
+	lastSeen time.Time
+}
+
+func newSession(state b.GameState, now time.Time) *Session {
+	return &Session{
+		GameID:   state.Game.ID,
+		SnakeID:  state.You.ID,
+		history:  make([]b.GameState, 0),
+		values:   make(map[string]interface{}),
+		lastSeen: now,
+	}
+}
+
+// Get Returns a value that a strategy kept from an earlier turn.
+func (s *Session) Get(key string) (interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set Keeps a value for later turns.
+func (s *Session) Set(key string, value interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.values[key] = value
+}
+
+// History Returns the previous turns of the game, oldest first.
+func (s *Session) History() []b.GameState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]b.GameState(nil), s.history...)
+}
+
+// Previous Returns the turn before the current one, if there was one.
+func (s *Session) Previous() (b.GameState, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.history) == 0 {
+		return b.GameState{}, false
+	}
+	return s.history[len(s.history)-1], true
+}
+
+// remember Adds a turn to the history once the snake has moved.
+func (s *Session) remember(state b.GameState) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.history = append(s.history, state)
+	if len(s.history) > maxHistory {
+		s.history = s.history[len(s.history)-maxHistory:]
+	}
+}
+
+// sessionKey Identifies a session. The same game may be played by more than one of our snakes.
+type sessionKey struct {
+	gameID  string
+	snakeID string
+}
+
+func keyOf(state b.GameState) sessionKey {
+	return sessionKey{gameID: state.Game.ID, snakeID: state.You.ID}
+}
+
+// Sessions The sessions of every game in progress. Safe to use from concurrent games.
+type Sessions struct {
+	mutex    sync.Mutex
+	sessions map[sessionKey]*Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewSessions(ttl time.Duration) *Sessions {
+	return &Sessions{
+		sessions: make(map[sessionKey]*Session),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Start Begins a new session for a game, forgetting any sessions that have expired.
+func (s *Sessions) Start(state b.GameState) *Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	s.expire(now)
+	session := newSession(state, now)
+	s.sessions[keyOf(state)] = session
+	return session
+}
+
+// Get Returns the session of a game. A session is started if the game was not seen
+// start, for example if the snake was restarted mid-game.
+func (s *Sessions) Get(state b.GameState) *Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	session, ok := s.sessions[keyOf(state)]
+	if !ok {
+		s.expire(now)
+		session = newSession(state, now)
+		s.sessions[keyOf(state)] = session
+	}
+	session.lastSeen = now
+	return session
+}
+
+// End Forgets the session of a game.
+func (s *Sessions) End(state b.GameState) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, keyOf(state))
+	s.expire(s.now())
+}
+
+// Len Returns the number of sessions.
+func (s *Sessions) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.sessions)
+}
+
+// expire Forgets the sessions that have been inactive for longer than the TTL.
+func (s *Sessions) expire(now time.Time) {
+	for key, session := range s.sessions {
+		if now.Sub(session.lastSeen) > s.ttl {
+			delete(s.sessions, key)
+		}
+	}
+}
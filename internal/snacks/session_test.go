@@ -0,0 +1,132 @@
+package snacks
+
+import (
+	"fmt"
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func game(gameID string, snakeID string, turn int) battlesnake.GameState {
+	state := state()
+	state.Game.ID = gameID
+	state.You.ID = snakeID
+	state.Turn = turn
+	return state
+}
+
+func Test_Sessions_StartGetEnd(t *testing.T) {
+	sessions := NewSessions(SessionTTL)
+	started := sessions.Start(game("game-1", "you", 0))
+	require.Equal(t, "game-1", started.GameID)
+	require.Equal(t, "you", started.SnakeID)
+	require.Same(t, started, sessions.Get(game("game-1", "you", 1)))
+
+	// Each of our snakes in a game has its own session
+	require.NotSame(t, started, sessions.Get(game("game-1", "teammate", 1)))
+	require.Equal(t, 2, sessions.Len())
+
+	sessions.End(game("game-1", "you", 2))
+	require.Equal(t, 1, sessions.Len())
+	require.NotSame(t, started, sessions.Get(game("game-1", "you", 3)))
+}
+
+func Test_Sessions_Expire(t *testing.T) {
+	now := time.Now()
+	sessions := NewSessions(time.Minute)
+	sessions.now = func() time.Time { return now }
+	sessions.Start(game("abandoned", "you", 0))
+	active := sessions.Start(game("active", "you", 0))
+
+	now = now.Add(50 * time.Second)
+	sessions.Get(game("active", "you", 1))
+
+	// The abandoned game is forgotten when the next game starts
+	now = now.Add(20 * time.Second)
+	sessions.Start(game("new", "you", 0))
+	require.Equal(t, 2, sessions.Len())
+	require.Same(t, active, sessions.Get(game("active", "you", 2)))
+}
+
+func Test_Session_Values(t *testing.T) {
+	session := NewSessions(SessionTTL).Start(game("game-1", "you", 0))
+	_, ok := session.Get("path")
+	require.False(t, ok)
+	session.Set("path", []battlesnake.Move{battlesnake.UP})
+	path, ok := session.Get("path")
+	require.True(t, ok)
+	require.Equal(t, []battlesnake.Move{battlesnake.UP}, path)
+}
+
+func Test_Session_History(t *testing.T) {
+	session := NewSessions(SessionTTL).Start(game("game-1", "you", 0))
+	_, ok := session.Previous()
+	require.False(t, ok)
+
+	for turn := 0; turn < maxHistory+10; turn++ {
+		session.remember(game("game-1", "you", turn))
+	}
+	history := session.History()
+	require.Len(t, history, maxHistory)
+	require.Equal(t, 10, history[0].Turn)
+	previous, ok := session.Previous()
+	require.True(t, ok)
+	require.Equal(t, maxHistory+9, previous.Turn)
+}
+
+func Test_Sessions_Concurrent(t *testing.T) {
+	sessions := NewSessions(SessionTTL)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(gameID string) {
+			defer wg.Done()
+			sessions.Start(game(gameID, "you", 0))
+			for turn := 1; turn < 50; turn++ {
+				session := sessions.Get(game(gameID, "you", turn))
+				session.Set("turn", turn)
+				session.remember(game(gameID, "you", turn))
+			}
+			sessions.End(game(gameID, "you", 50))
+		}(fmt.Sprintf("game-%d", g))
+	}
+	wg.Wait()
+	require.Equal(t, 0, sessions.Len())
+}
+
+// countingStrategy Remembers how many turns it has seen.
+type countingStrategy struct {
+	turns    []int
+	previous []int
+}
+
+func (c *countingStrategy) move(state battlesnake.GameState, scorecard *Scorecard) {
+	c.moveWithSession(state, &Session{values: make(map[string]interface{})}, scorecard)
+}
+
+func (c *countingStrategy) moveWithSession(state battlesnake.GameState, session *Session, _ *Scorecard) {
+	turns, _ := session.Get("turns")
+	count, _ := turns.(int)
+	session.Set("turns", count+1)
+	c.turns = append(c.turns, count+1)
+	if previous, ok := session.Previous(); ok {
+		c.previous = append(c.previous, previous.Turn)
+	}
+}
+
+func Test_StrategyDrivenSnake_Session(t *testing.T) {
+	counting := &countingStrategy{}
+	snake := &StrategyDrivenSnake{strategies: []strategy{counting}}
+	snake.Start(game("game-1", "you", 0))
+	for turn := 0; turn < 3; turn++ {
+		snake.Evaluate(game("game-1", "you", turn))
+	}
+	snake.Evaluate(game("game-2", "you", 0))
+	snake.End(game("game-1", "you", 3))
+
+	require.Equal(t, []int{1, 2, 3, 1}, counting.turns)
+	require.Equal(t, []int{0, 1}, counting.previous)
+	require.Equal(t, 1, snake.memory().Len())
+}
@@ -1,15 +1,26 @@
 package snacks
 
 import (
+	"fmt"
 	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 type strategy interface {
 	move(state battlesnake.GameState, scorecard *Scorecard)
 }
 
+// rememberingStrategy is implemented by strategies that keep state between the turns of a game.
+type rememberingStrategy interface {
+	moveWithSession(state battlesnake.GameState, session *Session, scorecard *Scorecard)
+}
+
 type StrategyDrivenSnake struct {
 	name       string
 	author     string
@@ -17,6 +28,9 @@ type StrategyDrivenSnake struct {
 	head       string
 	tail       string
 	strategies []strategy
+	sessions   *Sessions     // created when first needed
+	profiles   *ProfileStore // optional; what is known of opponents from earlier games
+	once       sync.Once
 }
 
 func DumbSnake() *StrategyDrivenSnake {
@@ -77,12 +91,90 @@ func BattleSnake() *StrategyDrivenSnake {
 			&NoCollisions{},
 			&MoveToFood{weight: 0.7},
 			&AvoidBiggerSnakes{weight: 1.8},
+			&AvoidLikelyHeadToHead{weight: 40},
 			&MoveToSpace{weight: 3.0},
 			&AttackSmallerSnakes{weight: 1.2},
+			&AvoidShrinkingHazards{weight: 1.0},
+			&AnticipateHazards{weight: 2.0, lookahead: 3},
+			&SearchAhead{weight: 40},
 		},
 	}
 }
 
+// SquadSnake A snake that plays alongside its teammates in squad games.
+func SquadSnake(squad *Squad) *StrategyDrivenSnake {
+	return &StrategyDrivenSnake{
+		name:   "Squad Snake",
+		author: "nickwallen",
+		color:  "#256D7B",
+		head:   "ski",
+		tail:   "coffee",
+		strategies: []strategy{
+			&StayInBounds{},
+			&NoCollisions{squad: squad},
+			&MoveToFood{weight: 0.7, squad: squad},
+			&AvoidBiggerSnakes{weight: 1.8, squad: squad},
+			&AvoidLikelyHeadToHead{weight: 40, squad: squad},
+			&MoveToSpace{weight: 3.0, squad: squad},
+			&AttackSmallerSnakes{weight: 1.2, squad: squad},
+		},
+	}
+}
+
+// ConstrictorSnake A snake for the constrictor ruleset where there is no food
+// and bodies never shrink, so survival depends on controlling space.
+func ConstrictorSnake() *StrategyDrivenSnake {
+	return &StrategyDrivenSnake{
+		name:   "Constrictor Snake",
+		author: "nickwallen",
+		color:  "#7B2569",
+		head:   "regular",
+		tail:   "regular",
+		strategies: []strategy{
+			&StayInBounds{},
+			&NoCollisions{},
+			&AvoidDeadEnds{},
+			&MoveToSpace{weight: 10.0},
+			&AvoidBiggerSnakes{weight: 0.5},
+		},
+	}
+}
+
+// NewSnake Returns one of the preset snakes by name; DUMB, HUNGRY, SOLO, BATTLE, SQUAD or CONSTRICTOR.
+// The squad is only used by the SQUAD snake.
+func NewSnake(name string, squad *Squad) (*StrategyDrivenSnake, error) {
+	switch name {
+	case "DUMB":
+		return DumbSnake(), nil
+	case "HUNGRY":
+		return HungrySnake(), nil
+	case "SOLO":
+		return SoloSurvivalSnake(), nil
+	case "BATTLE":
+		return BattleSnake(), nil
+	case "SQUAD":
+		return SquadSnake(squad), nil
+	case "CONSTRICTOR":
+		return ConstrictorSnake(), nil
+	default:
+		return nil, fmt.Errorf("unknown snake '%s'", name)
+	}
+}
+
+// SetProfiles Remembers opponents across games using the profiles in a store.
+func (s *StrategyDrivenSnake) SetProfiles(profiles *ProfileStore) {
+	s.profiles = profiles
+}
+
+// SetSearch Changes how the strategies that search ahead do so.
+func (s *StrategyDrivenSnake) SetSearch(config SearchConfig) {
+	for _, strategy := range s.strategies {
+		if search, ok := strategy.(*SearchAhead); ok {
+			search.config = config
+		}
+	}
+}
+
 func (s *StrategyDrivenSnake) Name() string {
 	return s.name
 }
@@ -102,6 +194,10 @@ func (s *StrategyDrivenSnake) Info() battlesnake.InfoResponse {
 
 // Start is called when your Battlesnake begins a game
 func (s *StrategyDrivenSnake) Start(state battlesnake.GameState) {
+	session := s.memory().Start(state)
+	if s.profiles != nil {
+		s.recallOpponents(state, session)
+	}
 	logger(state).
 		Str("snake", s.name).
 		Msg("start")
@@ -109,6 +205,10 @@ func (s *StrategyDrivenSnake) Start(state battlesnake.GameState) {
 
 // End is called when your Battlesnake finishes a game
 func (s *StrategyDrivenSnake) End(state battlesnake.GameState) {
+	if s.profiles != nil {
+		s.learnOpponents(state)
+	}
+	s.memory().End(state)
 	var gameResult string
 	isDraw := len(state.Board.Snakes) == 0
 	if isDraw {
@@ -121,6 +221,7 @@ func (s *StrategyDrivenSnake) End(state battlesnake.GameState) {
 			gameResult = "Lost"
 		}
 	}
+	metrics.GameResults.Inc(s.name, strings.ToLower(gameResult))
 	logger(state).
 		Msgf("'%s' %s in %d move(s)", s.Name(), gameResult, state.Turn+1)
 }
@@ -129,13 +230,125 @@ func (s *StrategyDrivenSnake) End(state battlesnake.GameState) {
 // Valid moves are UP, DOWN, LEFT, or RIGHT
 // See https://docs.b.com/api/example-move for available data
 func (s *StrategyDrivenSnake) Move(state battlesnake.GameState) battlesnake.MoveResponse {
+	return s.Evaluate(state).Response
+}
+
+// Evaluate Returns the next move along with the score each strategy gave to each move.
+func (s *StrategyDrivenSnake) Evaluate(state battlesnake.GameState) battlesnake.Evaluation {
+	session := s.memory().Get(state)
+	if s.profiles != nil {
+		opponentsOf(session, state).identify(state)
+	}
+	scorecard := s.score(state, session, func(strategy strategy, took time.Duration) {
+		metrics.StrategyLatency.Observe(took.Seconds(), strategyName(strategy))
+	})
+	move := scorecard.Best()
+	debug(state).Stringer("move", move).Msg("moved")
+	if event := debug(state); event.Enabled() {
+		event.Msgf("Board\n%s", battlesnake.RenderWithScores(state, scorecard.scoresByMove()))
+	}
+
+	session.remember(state)
+
+	explanation := scorecard.Explain()
+	if event := debug(state); event.Enabled() {
+		event.Interface("explanation", explanation).Msg("explained")
+	}
+	return battlesnake.Evaluation{
+		Response:    battlesnake.MoveResponse{Move: move},
+		Scores:      explanation.Scores(),
+		Explanation: &explanation,
+	}
+}
+
+// Preview Returns how the snake would move given any game state, without affecting the games it is
+// playing. The state is scored in a session of its own, so nothing remembered of a game in progress
+// is used or changed, and no metrics are recorded.
+func (s *StrategyDrivenSnake) Preview(state battlesnake.GameState) battlesnake.Evaluation {
+	scorecard := s.score(state, newSession(state, time.Now()), nil)
+	explanation := scorecard.Explain()
+	return battlesnake.Evaluation{
+		Response:    battlesnake.MoveResponse{Move: explanation.Chosen},
+		Scores:      explanation.Scores(),
+		Explanation: &explanation,
+	}
+}
+
+// score Asks each strategy to score the moves, optionally reporting how long each took.
+func (s *StrategyDrivenSnake) score(state battlesnake.GameState, session *Session, timed func(strategy, time.Duration)) *Scorecard {
 	scorecard := NewScorecard(state)
 	for _, strategy := range s.strategies {
-		strategy.move(state, scorecard)
+		started := time.Now()
+		if remembering, ok := strategy.(rememberingStrategy); ok {
+			remembering.moveWithSession(state, session, scorecard)
+		} else {
+			strategy.move(state, scorecard)
+		}
+		if timed != nil {
+			timed(strategy, time.Since(started))
+		}
 	}
-	move := scorecard.Best()
-	logger(state).Stringer("move", move).Msg("moved")
-	return battlesnake.MoveResponse{Move: move}
+	return scorecard
+}
+
+// recallOpponents Starts a game knowing what was learnt of each opponent in earlier games.
+func (s *StrategyDrivenSnake) recallOpponents(state battlesnake.GameState, session *Session) {
+	opponents := opponentsOf(session, state)
+	for _, opponent := range state.Board.Snakes {
+		if opponent.ID == state.You.ID || len(opponent.Name) == 0 {
+			continue
+		}
+		profile, err := s.profiles.Load(opponent)
+		if err != nil {
+			log.Warn().Err(err).Str("game-id", state.Game.ID).Msgf("Unable to load the profile of '%s'", opponent.Name)
+			continue
+		}
+		opponents.seed(profile.model(opponent.ID))
+		if profile.Games > 0 {
+			logger(state).Msgf("Know '%s' from %d game(s); outlasted us in %.0f%%, moves in %.0fms",
+				profile.Name, profile.Games, 100*profile.Strength(), profile.AverageLatencyMS())
+		}
+	}
+	opponents.identify(state)
+}
+
+// learnOpponents Adds what was learnt of each opponent in a game to their profiles.
+func (s *StrategyDrivenSnake) learnOpponents(state battlesnake.GameState) {
+	opponents := opponentsOf(s.memory().Get(state), state)
+	survivors := make(map[string]bool)
+	for _, snake := range state.Board.Snakes {
+		survivors[snake.ID] = true
+	}
+	for _, model := range opponents.all() {
+		if len(model.Name) == 0 {
+			continue
+		}
+		opponent := battlesnake.Snake{ID: model.ID, Name: model.Name, Customizations: model.Customizations}
+		outlasted := survivors[model.ID] && !survivors[state.You.ID]
+		err := s.profiles.learn(opponent, model, outlasted)
+		if err != nil {
+			log.Warn().Err(err).Str("game-id", state.Game.ID).Msgf("Unable to save the profile of '%s'", model.Name)
+		}
+	}
+}
+
+// memory Returns the sessions of the games this snake is playing.
+func (s *StrategyDrivenSnake) memory() *Sessions {
+	s.once.Do(func() {
+		if s.sessions == nil {
+			s.sessions = NewSessions(SessionTTL)
+		}
+	})
+	return s.sessions
+}
+
+// strategyName Returns the name of a strategy's type, like 'NoCollisions'.
+func strategyName(strategy strategy) string {
+	t := reflect.TypeOf(strategy)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
 }
 
 func logger(state battlesnake.GameState) *zerolog.Event {
@@ -1 +1,157 @@
 package snacks
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_StrategyDrivenSnake_Evaluate(t *testing.T) {
+	state := battlesnake.GameState{
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Food:   []battlesnake.Coord{{4, 0}},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{0, 0},
+		},
+	}
+	evaluation := HungrySnake().Evaluate(state)
+	require.Equal(t, battlesnake.RIGHT, evaluation.Response.Move)
+	require.Equal(t, 20, evaluation.Scores["move-to-closest-food"][battlesnake.RIGHT])
+	require.Equal(t, 25, evaluation.Scores["move-to-center"][battlesnake.RIGHT])
+
+	// Unsafe moves are not scored
+	require.NotContains(t, evaluation.Scores["move-to-center"], battlesnake.LEFT)
+}
+
+func Test_StrategyDrivenSnake_Explain(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . . # #
+		2 1 H . .
+		. . . # #
+	`)
+	evaluation := SoloSurvivalSnake().Evaluate(state)
+	require.NotNil(t, evaluation.Explanation)
+	require.Equal(t, evaluation.Response.Move, evaluation.Explanation.Chosen)
+
+	var deadEnds battlesnake.StrategyExplanation
+	for _, strategy := range evaluation.Explanation.Strategies {
+		if strategy.Name == "avoid-dead-ends" {
+			deadEnds = strategy
+		}
+	}
+	require.Contains(t, deadEnds.Vetoes, battlesnake.RIGHT)
+	require.Contains(t, deadEnds.Reasons, "Dead-end right! Have 2 square(s), need 3")
+}
+
+func Test_StrategyDrivenSnake_Metrics(t *testing.T) {
+	snake := HungrySnake()
+	strategies := metrics.StrategyLatency.Count("MoveToClosestFood")
+	snake.Evaluate(state())
+	require.Equal(t, strategies+1, metrics.StrategyLatency.Count("MoveToClosestFood"))
+
+	// The result of each game is counted
+	won := metrics.GameResults.Value(snake.Name(), "won")
+	lost := metrics.GameResults.Value(snake.Name(), "lost")
+	draw := metrics.GameResults.Value(snake.Name(), "draw")
+	you := battlesnake.Snake{ID: "you"}
+	snake.End(battlesnake.GameState{You: you, Board: battlesnake.Board{Snakes: []battlesnake.Snake{you}}})
+	snake.End(battlesnake.GameState{You: you, Board: battlesnake.Board{Snakes: []battlesnake.Snake{{ID: "them"}}}})
+	snake.End(battlesnake.GameState{You: you})
+	require.Equal(t, won+1, metrics.GameResults.Value(snake.Name(), "won"))
+	require.Equal(t, lost+1, metrics.GameResults.Value(snake.Name(), "lost"))
+	require.Equal(t, draw+1, metrics.GameResults.Value(snake.Name(), "draw"))
+}
+
+// Test_StrategyDrivenSnake_Concurrent Plays many games at once, with each turn of a game also moved
+// twice at the same time, as when the engine retries a slow request. Run with -race to catch unsafe
+// sharing of state between the games.
+func Test_StrategyDrivenSnake_Concurrent(t *testing.T) {
+	profiles, err := NewProfileStore(t.TempDir())
+	require.NoError(t, err)
+	for _, name := range []string{"BATTLE", "SQUAD", "CONSTRICTOR"} {
+		snake, err := NewSnake(name, NewSquad(""))
+		require.NoError(t, err)
+		snake.SetProfiles(profiles)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			gameID := name + "-" + strconv.Itoa(i)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				at := func(turn int) battlesnake.GameState {
+					state := chasingFood(turn)
+					state.Game.ID = gameID
+					return state
+				}
+				snake.Start(at(0))
+				for turn := 0; turn < 4; turn++ {
+					var moves sync.WaitGroup
+					for retry := 0; retry < 2; retry++ {
+						moves.Add(1)
+						go func(state battlesnake.GameState) {
+							defer moves.Done()
+							snake.Move(state)
+						}(at(turn))
+					}
+					moves.Wait()
+				}
+				snake.End(at(4))
+			}()
+		}
+		wg.Wait()
+		require.Zero(t, snake.memory().Len(), name)
+	}
+
+	// Every game taught us about the same opponent
+	profile, err := profiles.Load(chasingFood(0).Board.Snakes[1])
+	require.NoError(t, err)
+	require.Equal(t, 48, profile.Games)
+}
+
+// Test_StrategyDrivenSnake_Preview Evaluates a position from a game in progress through the debug
+// endpoint, which must leave what the snake remembers of the game alone.
+func Test_StrategyDrivenSnake_Preview(t *testing.T) {
+	snake := BattleSnake()
+	snake.SetSearch(SearchConfig{Deterministic: true})
+	snake.Start(chasingFood(0))
+	snake.Move(chasingFood(0))
+	snake.Move(chasingFood(1))
+
+	session := snake.memory().Get(chasingFood(1))
+	value, ok := session.Get(opponentsKey)
+	require.True(t, ok)
+	opponent := value.(*Opponents).Model("a")
+	history := session.History()
+	observations := opponent.Observations
+	strategies := metrics.StrategyLatency.Count("NoCollisions")
+
+	server := httptest.NewServer(battlesnake.NewSnakeServer(snake, nil, battlesnake.Logging{Logger: zerolog.Nop()}).Handler())
+	defer server.Close()
+	body, err := json.Marshal(chasingFood(2))
+	require.NoError(t, err)
+	response, err := http.Post(server.URL+"/debug/evaluate", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	var evaluation battlesnake.EvaluateResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&evaluation))
+	require.Equal(t, snake.Preview(chasingFood(2)).Response.Move, evaluation.Move)
+
+	require.Equal(t, 1, snake.memory().Len())
+	require.Same(t, session, snake.memory().Get(chasingFood(1)))
+	require.Equal(t, history, session.History())
+	require.Equal(t, observations, opponent.Observations)
+	require.Equal(t, strategies, metrics.StrategyLatency.Count("NoCollisions"))
+}
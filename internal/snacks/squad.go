@@ -0,0 +1,45 @@
+package snacks
+
+import (
+	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"strings"
+)
+
+// Squad Identifies the teammates of a snake in squad games. Snakes are teammates when
+// the game assigns them the same squad or, if configured, when their names share a prefix.
+// A nil Squad only recognises teammates by the squad assigned by the game.
+type Squad struct {
+	namePrefix string
+}
+
+func NewSquad(namePrefix string) *Squad {
+	return &Squad{
+		namePrefix: namePrefix,
+	}
+}
+
+// isTeammate Returns true if the snake is on the same team as you.
+func (s *Squad) isTeammate(state b.GameState, snake b.Snake) bool {
+	if snake.ID == state.You.ID {
+		return false
+	}
+	if state.You.Squad != "" && snake.Squad == state.You.Squad {
+		return true
+	}
+	if s == nil || s.namePrefix == "" {
+		return false
+	}
+	return strings.HasPrefix(snake.Name, s.namePrefix) && strings.HasPrefix(state.You.Name, s.namePrefix)
+}
+
+// isClaimedByTeammate Returns true if a teammate is closer to the food than you are.
+func (s *Squad) isClaimedByTeammate(state b.GameState, food b.Coord) bool {
+	grid := b.NewGrid(state)
+	dist := grid.DistanceTo(headOfSnake(state), food)
+	for _, snake := range state.Board.Snakes {
+		if s.isTeammate(state, snake) && grid.DistanceTo(snake.Head, food) < dist {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package snacks
+
+import (
+	"fmt"
+	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// squadState A squad game where your teammate 'a' is beside you and the opponent 'b' is in the corner.
+func squadState() battlesnake.GameState {
+	state := battlesnake.MustParseBoard(`
+		B . . . .
+		b . . A .
+		. . H a .
+		. . 1 a .
+		. . . . .
+	`)
+	state.Game.Ruleset = battlesnake.Ruleset{
+		Name: battlesnake.RulesetSquad,
+		Settings: battlesnake.RulesetSettings{
+			Squad: battlesnake.SquadSettings{AllowBodyCollisions: true},
+		},
+	}
+	squads := []string{"red", "red", "blue"}
+	for i := range state.Board.Snakes {
+		state.Board.Snakes[i].Name = fmt.Sprintf("snacks-%d", i+1)
+		state.Board.Snakes[i].Squad = squads[i]
+	}
+	state.You = state.Board.Snakes[0]
+	return state
+}
+
+func Test_Squad_IsTeammate(t *testing.T) {
+	state := squadState()
+	var squad *Squad
+	require.False(t, squad.isTeammate(state, state.Board.Snakes[0]))
+	require.True(t, squad.isTeammate(state, state.Board.Snakes[1]))
+	require.False(t, squad.isTeammate(state, state.Board.Snakes[2]))
+}
+
+func Test_Squad_IsTeammateByName(t *testing.T) {
+	state := squadState()
+	squad := NewSquad("snacks-")
+	require.True(t, squad.isTeammate(state, state.Board.Snakes[2]))
+	require.False(t, NewSquad("other-").isTeammate(state, state.Board.Snakes[2]))
+}
+
+func Test_Squad_AvoidBiggerSnakes(t *testing.T) {
+	state := squadState()
+	scorecard := NewScorecard(state)
+	strategy := AvoidBiggerSnakes{weight: 1.0}
+	strategy.move(state, scorecard)
+
+	// The bigger snake is a teammate, so the only snake to avoid is the equal size opponent
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.LEFT])
+	require.Equal(t, Score(4), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(4), scorecard.Scores()[battlesnake.DOWN])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}
+
+func Test_Squad_NoCollisions(t *testing.T) {
+	state := squadState()
+	scorecard := NewScorecard(state)
+	strategy := NoCollisions{}
+	strategy.move(state, scorecard)
+	require.Contains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
+
+	// Without body collisions, the teammate is an obstacle
+	state.Game.Ruleset.Settings.Squad.AllowBodyCollisions = false
+	scorecard = NewScorecard(state)
+	strategy.move(state, scorecard)
+	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
+}
+
+func Test_Squad_MoveToFood(t *testing.T) {
+	state := squadState()
+	state.Board.Food = []battlesnake.Coord{{4, 4}}
+	scorecard := NewScorecard(state)
+	strategy := MoveToFood{weight: 1.0}
+	strategy.move(state, scorecard)
+
+	// The teammate is closer to the food
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}
+
+func Test_Squad_AvoidDeadEnds(t *testing.T) {
+	state := squadState()
+	scorecard := NewScorecard(state)
+	strategy := AvoidDeadEnds{}
+	strategy.move(state, scorecard)
+	require.Contains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
+
+	// Without body collisions, the teammate is an obstacle
+	state.Game.Ruleset.Settings.Squad.AllowBodyCollisions = false
+	scorecard = NewScorecard(state)
+	strategy.move(state, scorecard)
+	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
+}
+
+func Test_Squad_MoveToSpace(t *testing.T) {
+	state := squadState()
+	scorecard := NewScorecard(state)
+	strategy := MoveToSpace{weight: 1.0}
+	strategy.move(state, scorecard)
+	require.Greater(t, scorecard.Scores()[battlesnake.RIGHT], Score(0), battlesnake.Render(state))
+
+	// Without body collisions, the teammate is an obstacle
+	state.Game.Ruleset.Settings.Squad.AllowBodyCollisions = false
+	scorecard = NewScorecard(state)
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT], battlesnake.Render(state))
+}
@@ -2,10 +2,14 @@ package snacks
 
 import (
 	"errors"
+	"fmt"
 	b "github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/maps"
 	"math"
 )
 
+var allMoves = []b.Move{b.RIGHT, b.LEFT, b.UP, b.DOWN}
+
 var (
 	ErrNoBiggerSnakes = errors.New("no bigger snakes found")
 	ErrNoFood         = errors.New("no food found")
@@ -16,6 +20,9 @@ type StayInBounds struct {
 }
 
 func (s *StayInBounds) move(state b.GameState, card *Scorecard) {
+	if state.Game.Ruleset.IsWrapped() {
+		return // There are no boundaries to stay within
+	}
 	scorecard := NewLoggingScorecard("stay-in-bounds", state, card)
 	head := headOfSnake(state)
 	if head.Right().X >= state.Board.Width || head.Right().Y < 0 {
@@ -34,6 +41,7 @@ func (s *StayInBounds) move(state b.GameState, card *Scorecard) {
 
 // NoCollisions allows a snake to avoid collisions with other snakes and itself.
 type NoCollisions struct {
+	squad *Squad
 }
 
 func (a *NoCollisions) move(state b.GameState, card *Scorecard) {
@@ -43,7 +51,11 @@ func (a *NoCollisions) move(state b.GameState, card *Scorecard) {
 	for _, square := range state.You.Body {
 		avoid = append(avoid, square)
 	}
+	passThroughTeammates := state.Game.Ruleset.Settings.Squad.AllowBodyCollisions
 	for _, opponent := range state.Board.Snakes {
+		if passThroughTeammates && a.squad.isTeammate(state, opponent) {
+			continue // Teammates can pass through one another
+		}
 		for _, square := range opponent.Body {
 			avoid = append(avoid, square)
 		}
@@ -53,18 +65,19 @@ func (a *NoCollisions) move(state b.GameState, card *Scorecard) {
 	}
 
 	scorecard := NewLoggingScorecard("no-collisions", state, card)
+	grid := b.NewGrid(state)
 	head := headOfSnake(state)
 	for _, square := range avoid {
-		if head.Right() == square {
+		if grid.Right(head) == square {
 			scorecard.Unsafe(b.RIGHT)
 		}
-		if head.Left() == square {
+		if grid.Left(head) == square {
 			scorecard.Unsafe(b.LEFT)
 		}
-		if head.Up() == square {
+		if grid.Up(head) == square {
 			scorecard.Unsafe(b.UP)
 		}
-		if head.Down() == square {
+		if grid.Down(head) == square {
 			scorecard.Unsafe(b.DOWN)
 		}
 	}
@@ -76,6 +89,9 @@ type MoveToClosestFood struct {
 }
 
 func (m MoveToClosestFood) move(state b.GameState, card *Scorecard) {
+	if state.Game.Ruleset.IsConstrictor() {
+		return // There is no food to move toward
+	}
 	head := headOfSnake(state)
 	closestFood, err := findNearbyFood(state, head)
 	if err == ErrNoFood {
@@ -84,16 +100,18 @@ func (m MoveToClosestFood) move(state b.GameState, card *Scorecard) {
 
 	// Incentivize moves that take us closer to the food
 	scorecard := NewLoggingScorecard("move-to-closest-food", state, card)
-	if head.X > closestFood.X {
+	scorecard.Reason("Closest food is at %s", closestFood)
+	dx, dy := b.NewGrid(state).Delta(head, closestFood)
+	if dx < 0 {
 		scorecard.Add(b.LEFT, m.weight)
 	}
-	if head.X < closestFood.X {
+	if dx > 0 {
 		scorecard.Add(b.RIGHT, m.weight)
 	}
-	if head.Y > closestFood.Y {
+	if dy < 0 {
 		scorecard.Add(b.DOWN, m.weight)
 	}
-	if head.Y < closestFood.Y {
+	if dy > 0 {
 		scorecard.Add(b.UP, m.weight)
 	}
 }
@@ -103,9 +121,10 @@ func findNearbyFood(state b.GameState, head b.Coord) (b.Coord, error) {
 	if len(state.Board.Food) == 0 {
 		return closestFood, ErrNoFood
 	}
+	grid := b.NewGrid(state)
 	minDist := math.MaxInt
 	for _, food := range state.Board.Food {
-		dist := head.DistanceTo(food)
+		dist := grid.DistanceTo(head, food)
 		if dist < minDist {
 			minDist = dist
 			closestFood = food
@@ -121,6 +140,9 @@ type MoveToCenter struct {
 }
 
 func (m *MoveToCenter) move(state b.GameState, card *Scorecard) {
+	if state.Game.Ruleset.IsWrapped() {
+		return // There are no edges to move away from
+	}
 	scorecard := NewLoggingScorecard("move-to-center", state, card)
 	head := headOfSnake(state)
 	centerX := float64(state.Board.Width) / float64(2)
@@ -147,6 +169,9 @@ type MoveToWalls struct {
 }
 
 func (m *MoveToWalls) move(state b.GameState, card *Scorecard) {
+	if state.Game.Ruleset.IsWrapped() {
+		return // There are no walls to move toward
+	}
 	scorecard := NewLoggingScorecard("move-to-walls", state, card)
 	head := headOfSnake(state)
 	centerX := float64(state.Board.Width) / float64(2)
@@ -167,15 +192,27 @@ func (m *MoveToWalls) move(state b.GameState, card *Scorecard) {
 	}
 }
 
-// AvoidBiggerSnakes allows a snake to move away from larger snakes
+// AvoidBiggerSnakes allows a snake to move away from larger snakes, or from where they are likely
+// to move next once how they move has been seen.
 type AvoidBiggerSnakes struct {
 	weight float64
+	squad  *Squad
 }
 
 func (m AvoidBiggerSnakes) move(state b.GameState, card *Scorecard) {
+	m.avoid(state, newOpponents(), card)
+}
+
+func (m AvoidBiggerSnakes) moveWithSession(state b.GameState, session *Session, card *Scorecard) {
+	m.avoid(state, opponentsOf(session, state), card)
+}
+
+func (m AvoidBiggerSnakes) avoid(state b.GameState, opponents *Opponents, card *Scorecard) {
 	var weightRight, weightLeft, weightUp, weightDown = 0.0, 0.0, 0.0, 0.0
+	scorecard := NewLoggingScorecard("avoid-bigger-snakes", state, card)
 	head := headOfSnake(state)
-	maxDist := state.Board.Width + state.Board.Height - 2
+	grid := b.NewGrid(state)
+	maxDist := grid.MaxDistance()
 	for _, snake := range state.Board.Snakes {
 		if state.You.Length > snake.Length {
 			continue // Ignore smaller snakes
@@ -183,27 +220,35 @@ func (m AvoidBiggerSnakes) move(state b.GameState, card *Scorecard) {
 		if snake.ID == state.You.ID {
 			continue // Ignore yourself
 		}
-
-		// The closer the snake is, the greater the incentive should be to move away
-		dist := head.DistanceTo(snake.Head)
-		weight := m.weight * float64(maxDist-dist)
-		debug(state).Msgf("Found bigger snake at %s, %d block(s) away", snake.Head, dist)
-
-		// Incentivize moves away from the bigger snake
-		if head.X > snake.Head.X {
-			weightRight += weight
-		} else {
-			weightLeft += weight
+		if m.squad.isTeammate(state, snake) {
+			continue // Ignore teammates
 		}
-		if head.Y > snake.Head.Y {
-			weightUp += weight
-		} else {
-			weightDown += weight
+		scorecard.Reason("Found bigger snake at %s, %d block(s) away", snake.Head, grid.DistanceTo(head, snake.Head))
+
+		// The closer the snake is likely to be, the greater the incentive should be to move away
+		for _, likely := range opponents.likelySquares(state, snake) {
+			dist := grid.DistanceTo(head, likely.square)
+			weight := m.weight * float64(maxDist-dist) * likely.chance
+			if likely.square != snake.Head {
+				scorecard.Reason("Bigger snake may move to %s, %d block(s) away, with chance %.2f", likely.square, dist, likely.chance)
+			}
+
+			// Incentivize moves away from the bigger snake
+			dx, dy := grid.Delta(head, likely.square)
+			if dx < 0 {
+				weightRight += weight
+			} else {
+				weightLeft += weight
+			}
+			if dy < 0 {
+				weightUp += weight
+			} else {
+				weightDown += weight
+			}
 		}
 	}
 
 	// Update the scorecard
-	scorecard := NewLoggingScorecard("avoid-bigger-snakes", state, card)
 	scorecard.Add(b.RIGHT, Score(weightRight))
 	scorecard.Add(b.LEFT, Score(weightLeft))
 	scorecard.Add(b.UP, Score(weightUp))
@@ -212,62 +257,65 @@ func (m AvoidBiggerSnakes) move(state b.GameState, card *Scorecard) {
 
 // AvoidDeadEnds allows a snake to avoid dead ends.
 type AvoidDeadEnds struct {
+	squad *Squad
 }
 
 func (m AvoidDeadEnds) move(state b.GameState, card *Scorecard) {
-	board := NewBoard(state)
+	board := newBoard(state, m.squad)
 	head := headOfSnake(state)
 	scorecard := NewLoggingScorecard("avoid-dead-ends", state, card)
-	spaceLeft := availableSpace(head.Left(), board)
+	spaceLeft := availableSpace(board.grid.Left(head), board)
 	if spaceLeft < state.You.Length {
 		scorecard.Unsafe(b.LEFT)
-		debug(state).Msgf("Dead-end left! Have %d square(s), need %d", spaceLeft, state.You.Length)
+		scorecard.Reason("Dead-end left! Have %d square(s), need %d", spaceLeft, state.You.Length)
 	}
 
-	spaceRight := availableSpace(head.Right(), board)
+	spaceRight := availableSpace(board.grid.Right(head), board)
 	if spaceRight < state.You.Length {
 		scorecard.Unsafe(b.RIGHT)
-		debug(state).Msgf("Dead-end right! Have %d square(s), need %d", spaceRight, state.You.Length)
+		scorecard.Reason("Dead-end right! Have %d square(s), need %d", spaceRight, state.You.Length)
 	}
 
-	spaceUp := availableSpace(head.Up(), board)
+	spaceUp := availableSpace(board.grid.Up(head), board)
 	if spaceUp < state.You.Length {
 		scorecard.Unsafe(b.UP)
-		debug(state).Msgf("Dead-end up! Have %d square(s), need %d", spaceRight, state.You.Length)
+		scorecard.Reason("Dead-end up! Have %d square(s), need %d", spaceUp, state.You.Length)
 	}
 
-	spaceDown := availableSpace(head.Down(), board)
+	spaceDown := availableSpace(board.grid.Down(head), board)
 	if spaceDown < state.You.Length {
 		scorecard.Unsafe(b.DOWN)
-		debug(state).Msgf("Dead-end down! Have %d square(s), need %d", spaceRight, state.You.Length)
+		scorecard.Reason("Dead-end down! Have %d square(s), need %d", spaceDown, state.You.Length)
 	}
 }
 
 // MoveToSpace allows a snake to move towards areas with more available space.
 type MoveToSpace struct {
 	weight float64
+	squad  *Squad
 }
 
 func (a MoveToSpace) move(state b.GameState, card *Scorecard) {
-	board := NewBoard(state)
+	board := newBoard(state, a.squad)
 	head := headOfSnake(state)
 	totalSpaces := state.Board.Height * state.Board.Width
 	scorecard := NewLoggingScorecard("move-to-space", state, card)
 
-	weightRight := float64(availableSpace(head.Right(), board)) / float64(totalSpaces) * 10 * a.weight
+	weightRight := float64(availableSpace(board.grid.Right(head), board)) / float64(totalSpaces) * 10 * a.weight
 	scorecard.Add(b.RIGHT, Score(weightRight))
 
-	weightLeft := float64(availableSpace(head.Left(), board)) / float64(totalSpaces) * 10 * a.weight
+	weightLeft := float64(availableSpace(board.grid.Left(head), board)) / float64(totalSpaces) * 10 * a.weight
 	scorecard.Add(b.LEFT, Score(weightLeft))
 
-	weightDown := float64(availableSpace(head.Down(), board)) / float64(totalSpaces) * 10 * a.weight
+	weightDown := float64(availableSpace(board.grid.Down(head), board)) / float64(totalSpaces) * 10 * a.weight
 	scorecard.Add(b.DOWN, Score(weightDown))
 
-	weightUp := float64(availableSpace(head.Up(), board)) / float64(totalSpaces) * 10 * a.weight
+	weightUp := float64(availableSpace(board.grid.Up(head), board)) / float64(totalSpaces) * 10 * a.weight
 	scorecard.Add(b.UP, Score(weightUp))
 }
 
 func availableSpace(start b.Coord, board *Board) int {
+	grid := board.grid
 	visited := make(map[b.Coord]bool, 0)
 	space := 0
 	toVisit := make([]b.Coord, 0)
@@ -280,7 +328,7 @@ func availableSpace(start b.Coord, board *Board) int {
 			visited[curr] = true
 			if board.isEmpty(curr) {
 				space += 1
-				toVisit = append(toVisit, curr.Right(), curr.Left(), curr.Up(), curr.Down())
+				toVisit = append(toVisit, grid.Right(curr), grid.Left(curr), grid.Up(curr), grid.Down(curr))
 			}
 		}
 	}
@@ -289,23 +337,32 @@ func availableSpace(start b.Coord, board *Board) int {
 
 type Board struct {
 	occupied map[b.Coord]bool
-	width    int
-	height   int
+	grid     b.Grid
 }
 
 func NewBoard(state b.GameState) *Board {
+	return newBoard(state, nil)
+}
+
+// newBoard Returns the board as seen by a member of a squad, who can pass through its teammates
+// when the game allows body collisions between them.
+func newBoard(state b.GameState, squad *Squad) *Board {
 	return &Board{
-		occupied: build(state),
-		width:    state.Board.Height,
-		height:   state.Board.Width,
+		occupied: build(state, squad),
+		grid:     b.NewGrid(state),
 	}
 }
 
-func build(state b.GameState) map[b.Coord]bool {
+func build(state b.GameState, squad *Squad) map[b.Coord]bool {
 	board := make(map[b.Coord]bool)
 
-	// Mark all the snakes
+	// Mark all the snakes. A snake's tail is never freed, which is also
+	// true of the constrictor ruleset where snakes grow every turn.
+	passThroughTeammates := state.Game.Ruleset.Settings.Squad.AllowBodyCollisions
 	for _, snake := range state.Board.Snakes {
+		if passThroughTeammates && squad.isTeammate(state, snake) {
+			continue // Teammates can pass through one another
+		}
 		for _, body := range snake.Body {
 			board[body] = true
 		}
@@ -320,63 +377,105 @@ func build(state b.GameState) map[b.Coord]bool {
 }
 
 func (b *Board) isEmpty(coord b.Coord) bool {
-	if coord.X < 0 {
-		return false
-	}
-	if coord.X >= b.width {
-		return false
-	}
-	if coord.Y < 0 {
-		return false
-	}
-	if coord.Y >= b.height {
+	coord = b.grid.Wrap(coord)
+	if !b.grid.InBounds(coord) {
 		return false
 	}
 	return !b.occupied[coord]
 }
 
-// MoveToFood allows a snake to prefer moves where more food exists.
+// MoveToFood allows a snake to prefer moves where more food exists. Without food on the board, a
+// snake heads for the closest square where the map can place food.
 type MoveToFood struct {
 	weight float64
+	squad  *Squad
 }
 
 func (m MoveToFood) move(state b.GameState, card *Scorecard) {
+	if state.Game.Ruleset.IsConstrictor() {
+		return // There is no food to move toward
+	}
 	var foodToRight, foodToLeft, foodAbove, foodBelow = 0.0, 0.0, 0.0, 0.0
 	head := headOfSnake(state)
-	maxDist := state.Board.Width + state.Board.Height - 2
+	grid := b.NewGrid(state)
+	maxDist := grid.MaxDistance()
+	scorecard := NewLoggingScorecard("move-to-food", state, card)
+	targets := make([]b.Coord, 0, len(state.Board.Food))
 	for _, food := range state.Board.Food {
+		if !m.squad.isClaimedByTeammate(state, food) {
+			targets = append(targets, food) // Otherwise, leave the food for a teammate
+		}
+	}
+	if len(targets) == 0 {
+		spawn, ok := closestFoodSpawn(state, grid, head)
+		if ok {
+			scorecard.Reason("No food, so heading for %s where food can appear", spawn)
+			targets = append(targets, spawn)
+		}
+	}
+	for _, food := range targets {
+
 		// The closer the food, the greater the weight
-		foodWeight := float64(maxDist-head.DistanceTo(food)) * m.weight
-		if food.X > head.X {
+		foodWeight := float64(maxDist-grid.DistanceTo(head, food)) * m.weight
+		dx, dy := grid.Delta(head, food)
+		if dx > 0 {
 			foodToRight += foodWeight
 		}
-		if food.X < head.X {
+		if dx < 0 {
 			foodToLeft += foodWeight
 		}
-		if food.Y > head.Y {
+		if dy > 0 {
 			foodAbove += foodWeight
 		}
-		if food.Y < head.Y {
+		if dy < 0 {
 			foodBelow += foodWeight
 		}
 	}
 
 	// Update the scorecard
-	scorecard := NewLoggingScorecard("move-to-food", state, card)
 	scorecard.Add(b.RIGHT, Score(foodToRight))
 	scorecard.Add(b.LEFT, Score(foodToLeft))
 	scorecard.Add(b.UP, Score(foodAbove))
 	scorecard.Add(b.DOWN, Score(foodBelow))
 }
 
+// closestFoodSpawn Returns the closest square, other than where the snake is, where the map can place
+// food, or false if food can appear anywhere or nowhere.
+func closestFoodSpawn(state b.GameState, grid b.Grid, head b.Coord) (b.Coord, bool) {
+	var closest b.Coord
+	found := false
+	for _, spawn := range maps.ForGame(state).FoodSpawns(state) {
+		if spawn == head {
+			continue
+		}
+		if !found || grid.DistanceTo(head, spawn) < grid.DistanceTo(head, closest) {
+			closest, found = spawn, true
+		}
+	}
+	return closest, found
+}
+
+// AttackSmallerSnakes allows a snake to move toward smaller snakes, or toward where they are likely
+// to move next once how they move has been seen.
 type AttackSmallerSnakes struct {
 	weight float64
+	squad  *Squad
 }
 
 func (a AttackSmallerSnakes) move(state b.GameState, card *Scorecard) {
+	a.attack(state, newOpponents(), card)
+}
+
+func (a AttackSmallerSnakes) moveWithSession(state b.GameState, session *Session, card *Scorecard) {
+	a.attack(state, opponentsOf(session, state), card)
+}
+
+func (a AttackSmallerSnakes) attack(state b.GameState, opponents *Opponents, card *Scorecard) {
 	var weightRight, weightLeft, weightUp, weightDown = 0.0, 0.0, 0.0, 0.0
+	scorecard := NewLoggingScorecard("attack-smaller-snakes", state, card)
 	head := headOfSnake(state)
-	maxDist := state.Board.Width + state.Board.Height - 2
+	grid := b.NewGrid(state)
+	maxDist := grid.MaxDistance()
 	for _, snake := range state.Board.Snakes {
 		if state.You.Length <= snake.Length {
 			continue // Ignore bigger snakes
@@ -384,29 +483,233 @@ func (a AttackSmallerSnakes) move(state b.GameState, card *Scorecard) {
 		if snake.ID == state.You.ID {
 			continue // Ignore yourself
 		}
-
-		// The closer the snake is, the greater the incentive should be to attack
-		dist := head.DistanceTo(snake.Head)
-		weight := a.weight * float64(maxDist-dist)
-		debug(state).Msgf("Found smaller snake at %s, %d block(s) away", snake.Head, dist)
-
-		// Incentivize moves toward the smaller snake
-		if head.X > snake.Head.X {
-			weightLeft += weight
-		} else {
-			weightRight += weight
+		if a.squad.isTeammate(state, snake) {
+			continue // Ignore teammates
 		}
-		if head.Y > snake.Head.Y {
-			weightDown += weight
-		} else {
-			weightUp += weight
+		scorecard.Reason("Found smaller snake at %s, %d block(s) away", snake.Head, grid.DistanceTo(head, snake.Head))
+
+		// The closer the snake is likely to be, the greater the incentive should be to attack
+		for _, likely := range opponents.likelySquares(state, snake) {
+			dist := grid.DistanceTo(head, likely.square)
+			weight := a.weight * float64(maxDist-dist) * likely.chance
+			if likely.square != snake.Head {
+				scorecard.Reason("Smaller snake may move to %s, %d block(s) away, with chance %.2f", likely.square, dist, likely.chance)
+			}
+
+			// Incentivize moves toward the smaller snake
+			dx, dy := grid.Delta(head, likely.square)
+			if dx < 0 {
+				weightLeft += weight
+			} else {
+				weightRight += weight
+			}
+			if dy < 0 {
+				weightDown += weight
+			} else {
+				weightUp += weight
+			}
 		}
 	}
 
 	// Update the scorecard
-	scorecard := NewLoggingScorecard("attack-smaller-snakes", state, card)
 	scorecard.Add(b.RIGHT, Score(weightRight))
 	scorecard.Add(b.LEFT, Score(weightLeft))
 	scorecard.Add(b.UP, Score(weightUp))
 	scorecard.Add(b.DOWN, Score(weightDown))
 }
+
+// AvoidShrinkingHazards allows a snake to move toward the area that will remain safe after the
+// hazards of the royale ruleset next shrink the board.
+type AvoidShrinkingHazards struct {
+	weight float64
+}
+
+func (a AvoidShrinkingHazards) move(state b.GameState, card *Scorecard) {
+	shrinkEvery := state.Game.Ruleset.Settings.Royale.ShrinkEveryNTurns
+	if !state.Game.Ruleset.IsRoyale() || shrinkEvery <= 0 {
+		return // The hazards never shrink
+	}
+
+	// The closer the next shrink, the greater the incentive to reach the safe zone
+	turnsUntilShrink := shrinkEvery - state.Turn%shrinkEvery
+	urgency := float64(shrinkEvery-turnsUntilShrink+1) / float64(shrinkEvery)
+	zone := predictSafeZone(state)
+	scorecard := NewLoggingScorecard("avoid-shrinking-hazards", state, card)
+	scorecard.Reason("Next shrink in %d turn(s), safe zone is %s", turnsUntilShrink, zone)
+
+	grid := b.NewGrid(state)
+	maxDist := grid.MaxDistance()
+	head := headOfSnake(state)
+	for _, move := range allMoves {
+		dist := zone.distanceTo(grid.Move(head, move))
+		scorecard.Add(move, Score(a.weight*urgency*float64(maxDist-dist)))
+	}
+}
+
+// safeZone A rectangular area of the board free of hazards.
+type safeZone struct {
+	min b.Coord
+	max b.Coord
+}
+
+func (z safeZone) String() string {
+	return fmt.Sprintf("%s-%s", z.min, z.max)
+}
+
+// distanceTo Returns the distance from a coordinate to the nearest square in the zone.
+func (z safeZone) distanceTo(coord b.Coord) int {
+	dist := 0
+	if coord.X < z.min.X {
+		dist += z.min.X - coord.X
+	}
+	if coord.X > z.max.X {
+		dist += coord.X - z.max.X
+	}
+	if coord.Y < z.min.Y {
+		dist += z.min.Y - coord.Y
+	}
+	if coord.Y > z.max.Y {
+		dist += coord.Y - z.max.Y
+	}
+	return dist
+}
+
+// currentSafeZone Returns the area of the board not yet covered by royale hazards.
+func currentSafeZone(state b.GameState) safeZone {
+	hazards := make(map[b.Coord]bool)
+	for _, hazard := range state.Board.Hazards {
+		hazards[hazard] = true
+	}
+	zone := safeZone{
+		min: b.Coord{X: state.Board.Width, Y: state.Board.Height},
+		max: b.Coord{X: -1, Y: -1},
+	}
+	for x := 0; x < state.Board.Width; x++ {
+		for y := 0; y < state.Board.Height; y++ {
+			if hazards[b.Coord{X: x, Y: y}] {
+				continue
+			}
+			if x < zone.min.X {
+				zone.min.X = x
+			}
+			if y < zone.min.Y {
+				zone.min.Y = y
+			}
+			if x > zone.max.X {
+				zone.max.X = x
+			}
+			if y > zone.max.Y {
+				zone.max.Y = y
+			}
+		}
+	}
+	return zone
+}
+
+// predictSafeZone Returns the area that remains safe after the next shrink. The royale ruleset
+// shrinks a random side of the board, so only the squares away from every edge are certain to be safe.
+func predictSafeZone(state b.GameState) safeZone {
+	zone := currentSafeZone(state)
+	if zone.max.X-zone.min.X >= 2 {
+		zone.min.X += 1
+		zone.max.X -= 1
+	}
+	if zone.max.Y-zone.min.Y >= 2 {
+		zone.min.Y += 1
+		zone.max.Y -= 1
+	}
+	return zone
+}
+
+// AnticipateHazards allows a snake to avoid squares where the map will place hazards in the coming turns.
+type AnticipateHazards struct {
+	weight    float64
+	lookahead int // the number of turns to look ahead
+}
+
+func (a AnticipateHazards) move(state b.GameState, card *Scorecard) {
+	gameMap := maps.ForGame(state)
+	if gameMap.Static() {
+		return // The hazards will not change
+	}
+
+	// Predict the hazards for each of the coming turns
+	predicted := make([]map[b.Coord]bool, 0, a.lookahead)
+	for turn := state.Turn + 1; turn <= state.Turn+a.lookahead; turn++ {
+		hazards := make(map[b.Coord]bool)
+		for _, hazard := range gameMap.Hazards(state, turn) {
+			hazards[hazard] = true
+		}
+		predicted = append(predicted, hazards)
+	}
+
+	// The longer a square remains free of hazards, the greater the incentive
+	grid := b.NewGrid(state)
+	head := headOfSnake(state)
+	scorecard := NewLoggingScorecard("anticipate-hazards", state, card)
+	for _, move := range allMoves {
+		next := grid.Move(head, move)
+		safeTurns := 0
+		for _, hazards := range predicted {
+			if hazards[next] {
+				scorecard.Reason("Hazard expected at %s in %d turn(s)", next, safeTurns+1)
+				break
+			}
+			safeTurns += 1
+		}
+		scorecard.Add(move, Score(a.weight*float64(safeTurns)))
+	}
+}
+
+// AvoidLikelyHeadToHead allows a snake to avoid the squares that bigger snakes are likely to move to
+// next. The likelihood is predicted from how each snake has moved so far in the game. Without that
+// history, every move a snake could make is thought equally likely.
+type AvoidLikelyHeadToHead struct {
+	weight float64
+	squad  *Squad
+}
+
+func (a AvoidLikelyHeadToHead) move(state b.GameState, card *Scorecard) {
+	a.avoid(state, newOpponents(), card)
+}
+
+func (a AvoidLikelyHeadToHead) moveWithSession(state b.GameState, session *Session, card *Scorecard) {
+	a.avoid(state, opponentsOf(session, state), card)
+}
+
+func (a AvoidLikelyHeadToHead) avoid(state b.GameState, opponents *Opponents, card *Scorecard) {
+	scorecard := NewLoggingScorecard("avoid-likely-head-to-head", state, card)
+	grid := b.NewGrid(state)
+	head := headOfSnake(state)
+
+	// The chance that each move does not meet a bigger snake head-on
+	safety := make(map[b.Move]float64)
+	for _, move := range allMoves {
+		safety[move] = 1.0
+	}
+	for _, snake := range state.Board.Snakes {
+		if state.You.Length > snake.Length {
+			continue // Ignore smaller snakes
+		}
+		if snake.ID == state.You.ID {
+			continue // Ignore yourself
+		}
+		if a.squad.isTeammate(state, snake) {
+			continue // Ignore teammates
+		}
+		model := opponents.Model(snake.ID)
+		predictions := model.Predict(state, snake)
+		scorecard.Reason("Snake at %s is %s, predicted %s", snake.Head, model, formatPredictions(predictions))
+		for theirMove, chance := range predictions {
+			theirNext := grid.Move(snake.Head, theirMove)
+			for _, move := range allMoves {
+				if grid.Move(head, move) == theirNext {
+					safety[move] *= 1 - chance
+				}
+			}
+		}
+	}
+	for _, move := range allMoves {
+		scorecard.Add(move, Score(a.weight*safety[move]))
+	}
+}
@@ -2,6 +2,7 @@ package snacks
 
 import (
 	"github.com/nickwallen/battlesnake-snacks/internal/battlesnake"
+	"github.com/nickwallen/battlesnake-snacks/internal/maps"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -19,7 +20,7 @@ func Test_StayInBounds_BottomLeft(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.UP}, scorecard.SafeMoves())
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.UP}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_StayInBounds_BottomRight(t *testing.T) {
@@ -35,7 +36,7 @@ func Test_StayInBounds_BottomRight(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.ElementsMatch(t, []battlesnake.Move{battlesnake.LEFT, battlesnake.UP}, scorecard.SafeMoves())
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.LEFT, battlesnake.UP}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_StayInBounds_TopRight(t *testing.T) {
@@ -51,7 +52,7 @@ func Test_StayInBounds_TopRight(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.ElementsMatch(t, []battlesnake.Move{battlesnake.DOWN, battlesnake.LEFT}, scorecard.SafeMoves())
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.DOWN, battlesnake.LEFT}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_StayInBounds_TopLeft(t *testing.T) {
@@ -67,7 +68,7 @@ func Test_StayInBounds_TopLeft(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.DOWN}, scorecard.SafeMoves())
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.DOWN}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_StayInBounds_Middle(t *testing.T) {
@@ -83,7 +84,7 @@ func Test_StayInBounds_Middle(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.LEFT, battlesnake.UP, battlesnake.DOWN}, scorecard.SafeMoves())
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.LEFT, battlesnake.UP, battlesnake.DOWN}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_StayInBounds_OutOfBounds(t *testing.T) {
@@ -99,7 +100,7 @@ func Test_StayInBounds_OutOfBounds(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := StayInBounds{}
 	strategy.move(state, scorecard)
-	require.Equal(t, []battlesnake.Move{}, scorecard.SafeMoves())
+	require.Equal(t, []battlesnake.Move{}, scorecard.SafeMoves(), battlesnake.Render(state))
 }
 
 func Test_NoCollision_AvoidSelf(t *testing.T) {
@@ -119,7 +120,7 @@ func Test_NoCollision_AvoidSelf(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := NoCollisions{}
 	strategy.move(state, scorecard)
-	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT)
+	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
 }
 
 func Test_NoCollision_AvoidOpponent(t *testing.T) {
@@ -148,7 +149,7 @@ func Test_NoCollision_AvoidOpponent(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := NoCollisions{}
 	strategy.move(state, scorecard)
-	require.NotContains(t, scorecard.SafeMoves(), battlesnake.LEFT)
+	require.NotContains(t, scorecard.SafeMoves(), battlesnake.LEFT, battlesnake.Render(state))
 }
 
 func Test_MoveToCenter_BottomLeft(t *testing.T) {
@@ -450,36 +451,11 @@ func Test_Board_OutOfBounds(t *testing.T) {
 }
 
 func Test_MoveToSpace(t *testing.T) {
-	state := battlesnake.GameState{
-		Board: battlesnake.Board{
-			Height: 3,
-			Width:  3,
-			Snakes: []battlesnake.Snake{
-				{
-					ID:   "you",
-					Head: battlesnake.Coord{2, 1},
-					Body: []battlesnake.Coord{
-						{2, 1},
-						{1, 1},
-						{1, 0},
-						{0, 0},
-					},
-					Length: 4,
-				},
-			},
-		},
-		You: battlesnake.Snake{
-			ID:   "you",
-			Head: battlesnake.Coord{2, 1},
-			Body: []battlesnake.Coord{
-				{2, 1},
-				{1, 1},
-				{1, 0},
-				{0, 0},
-			},
-			Length: 4,
-		},
-	}
+	state := battlesnake.MustParseBoard(`
+		. . .
+		. 1 H
+		3 2 .
+	`)
 	scorecard := NewScorecard(state)
 	strategy := MoveToSpace{weight: 1.5}
 	strategy.move(state, scorecard)
@@ -532,47 +508,48 @@ func Test_MoveToFood_WithFood(t *testing.T) {
 	require.Equal(t, Score(6), scorecard.Scores()[battlesnake.UP])
 }
 
-func Test_AvoidDeadEnds_DeadEnd(t *testing.T) {
+func Test_MoveToFood_FoodSpawns(t *testing.T) {
+	// Without food, the snake heads out of the hazard to where food can appear
 	state := battlesnake.GameState{
+		Game: battlesnake.Game{Map: maps.Columns},
 		Board: battlesnake.Board{
 			Height: 5,
 			Width:  5,
-			Snakes: []battlesnake.Snake{
-				{
-					ID:   "you",
-					Head: battlesnake.Coord{2, 1},
-					Body: []battlesnake.Coord{
-						{2, 1},
-						{1, 1},
-						{0, 1},
-					},
-					Length: 3,
-				},
-			},
-			Hazards: []battlesnake.Coord{
-				{3, 0},
-				{3, 2},
-				{4, 0},
-				{4, 2},
-			},
+			Food:   []battlesnake.Coord{},
 		},
 		You: battlesnake.Snake{
-			ID:   "you",
-			Head: battlesnake.Coord{2, 1},
-			Body: []battlesnake.Coord{
-				{2, 1},
-				{1, 1},
-				{0, 1},
-			},
-			Length: 3,
+			Head: battlesnake.Coord{1, 1},
 		},
 	}
 	scorecard := NewScorecard(state)
+	strategy := MoveToFood{weight: 1.5}
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(10), scorecard.Scores()[battlesnake.DOWN])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.LEFT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+
+	// On the empty map food never appears
+	state.Game.Map = maps.Empty
+	scorecard = NewScorecard(state)
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.DOWN])
+}
+
+func Test_AvoidDeadEnds_DeadEnd(t *testing.T) {
+	state := battlesnake.MustParseBoard(`
+		. . . . .
+		. . . . .
+		. . . # #
+		2 1 H . .
+		. . . # #
+	`)
+	scorecard := NewScorecard(state)
 	strategy := AvoidDeadEnds{}
 	strategy.move(state, scorecard)
 
 	// Right is a dead-end!
-	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT)
+	require.NotContains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
 }
 
 func Test_AvoidDeadEnds_NotADeadEnd(t *testing.T) {
@@ -611,7 +588,7 @@ func Test_AvoidDeadEnds_NotADeadEnd(t *testing.T) {
 	scorecard := NewScorecard(state)
 	strategy := AvoidDeadEnds{}
 	strategy.move(state, scorecard)
-	require.Contains(t, scorecard.SafeMoves(), battlesnake.RIGHT)
+	require.Contains(t, scorecard.SafeMoves(), battlesnake.RIGHT, battlesnake.Render(state))
 }
 
 func Test_AttackSmallerSnakes_SmallerSnake(t *testing.T) {
@@ -740,3 +717,250 @@ func Test_AttackSmallerSnakes_NoSnakes(t *testing.T) {
 	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
 	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
 }
+
+func wrapped() battlesnake.Game {
+	return battlesnake.Game{
+		Ruleset: battlesnake.Ruleset{Name: battlesnake.RulesetWrapped},
+	}
+}
+
+func Test_StayInBounds_Wrapped(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: wrapped(),
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{0, 0},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := StayInBounds{}
+	strategy.move(state, scorecard)
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.LEFT, battlesnake.UP, battlesnake.DOWN}, scorecard.SafeMoves(), battlesnake.Render(state))
+}
+
+func Test_NoCollision_Wrapped(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: wrapped(),
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Snakes: []battlesnake.Snake{
+				{
+					ID:   "opponent",
+					Head: battlesnake.Coord{4, 0},
+					Body: []battlesnake.Coord{
+						{4, 0},
+						{4, 1},
+					},
+				},
+			},
+		},
+		You: battlesnake.Snake{
+			ID:   "you",
+			Head: battlesnake.Coord{0, 0},
+			Body: []battlesnake.Coord{
+				{0, 0},
+				{0, 1},
+			},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := NoCollisions{}
+	strategy.move(state, scorecard)
+	require.ElementsMatch(t, []battlesnake.Move{battlesnake.RIGHT, battlesnake.DOWN}, scorecard.SafeMoves(), battlesnake.Render(state))
+}
+
+func Test_MoveToClosestFood_Wrapped(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: wrapped(),
+		Board: battlesnake.Board{
+			Height: 11,
+			Width:  11,
+			Food: []battlesnake.Coord{
+				{10, 5},
+			},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{0, 5},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := MoveToClosestFood{weight: 10}
+	strategy.move(state, scorecard)
+	require.Equal(t, battlesnake.LEFT, scorecard.Best())
+}
+
+func Test_Board_Wrapped(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: wrapped(),
+		Board: battlesnake.Board{
+			Height: 2,
+			Width:  2,
+			Hazards: []battlesnake.Coord{
+				{0, 1},
+			},
+		},
+	}
+	board := NewBoard(state)
+	require.Equal(t, true, board.isEmpty(battlesnake.Coord{-1, 0}))
+	require.Equal(t, true, board.isEmpty(battlesnake.Coord{2, 2}))
+	require.Equal(t, false, board.isEmpty(battlesnake.Coord{0, -1}))
+}
+
+func constrictor() battlesnake.Game {
+	return battlesnake.Game{
+		Ruleset: battlesnake.Ruleset{Name: battlesnake.RulesetConstrictor},
+	}
+}
+
+func Test_MoveToFood_Constrictor(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: constrictor(),
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Food: []battlesnake.Coord{
+				{4, 4},
+			},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{2, 2},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := MoveToFood{weight: 1.5}
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}
+
+func Test_MoveToClosestFood_Constrictor(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: constrictor(),
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Food: []battlesnake.Coord{
+				{4, 4},
+			},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{2, 2},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := MoveToClosestFood{weight: 10}
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}
+
+func royale(shrinkEveryNTurns int) battlesnake.Game {
+	return battlesnake.Game{
+		Ruleset: battlesnake.Ruleset{
+			Name: battlesnake.RulesetRoyale,
+			Settings: battlesnake.RulesetSettings{
+				Royale: battlesnake.RoyaleSettings{ShrinkEveryNTurns: shrinkEveryNTurns},
+			},
+		},
+	}
+}
+
+func Test_PredictSafeZone(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: royale(5),
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Hazards: []battlesnake.Coord{
+				{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4},
+			},
+		},
+	}
+	require.Equal(t, safeZone{min: battlesnake.Coord{1, 0}, max: battlesnake.Coord{4, 4}}, currentSafeZone(state))
+	require.Equal(t, safeZone{min: battlesnake.Coord{2, 1}, max: battlesnake.Coord{3, 3}}, predictSafeZone(state))
+}
+
+func Test_AvoidShrinkingHazards_MoveToSafeZone(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: royale(5),
+		Turn: 4,
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+			Hazards: []battlesnake.Coord{
+				{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4},
+			},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{1, 2},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := AvoidShrinkingHazards{weight: 1.0}
+	strategy.move(state, scorecard)
+	require.Equal(t, battlesnake.RIGHT, scorecard.Best())
+	require.Equal(t, Score(7), scorecard.Scores()[battlesnake.UP])
+	require.Equal(t, Score(8), scorecard.Scores()[battlesnake.RIGHT])
+}
+
+func Test_AvoidShrinkingHazards_NotRoyale(t *testing.T) {
+	state := battlesnake.GameState{
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{0, 0},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := AvoidShrinkingHazards{weight: 1.0}
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}
+
+func Test_AnticipateHazards_Spiral(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: battlesnake.Game{Map: "hz_spiral"},
+		Turn: 2,
+		Board: battlesnake.Board{
+			Height:  5,
+			Width:   5,
+			Hazards: []battlesnake.Coord{{2, 2}},
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{3, 3},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := AnticipateHazards{weight: 1.0, lookahead: 3}
+	strategy.move(state, scorecard)
+
+	// The spiral grows into the square below on the next turn
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.DOWN])
+	require.Equal(t, Score(3), scorecard.Scores()[battlesnake.UP])
+	require.Equal(t, Score(3), scorecard.Scores()[battlesnake.RIGHT])
+	require.Equal(t, Score(3), scorecard.Scores()[battlesnake.LEFT])
+}
+
+func Test_AnticipateHazards_StaticMap(t *testing.T) {
+	state := battlesnake.GameState{
+		Game: battlesnake.Game{Map: "standard"},
+		Board: battlesnake.Board{
+			Height: 5,
+			Width:  5,
+		},
+		You: battlesnake.Snake{
+			Head: battlesnake.Coord{3, 3},
+		},
+	}
+	scorecard := NewScorecard(state)
+	strategy := AnticipateHazards{weight: 1.0, lookahead: 3}
+	strategy.move(state, scorecard)
+	require.Equal(t, Score(0), scorecard.Scores()[battlesnake.UP])
+}